@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+const (
+	// tokenVersionTTL is how long a user's token version is cached. A
+	// revocation made through another server takes up to this long to
+	// reach this one.
+	tokenVersionTTL        = 30 * time.Second
+	maxCachedTokenVersions = 10000
+)
+
+var errTokenRevoked = errors.New("access token has been revoked")
+
+type tokenVersionEntry struct {
+	version   int32
+	fetchedAt time.Time
+}
+
+// tokenVersionCache keeps recently used token versions in memory, so that
+// checking an access token does not cost a database query every time.
+type tokenVersionCache struct {
+	db  database.Store
+	ttl time.Duration
+	// now returns the current time. Tests can replace it.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]tokenVersionEntry
+}
+
+func newTokenVersionCache(db database.Store) *tokenVersionCache {
+	return &tokenVersionCache{
+		db:      db,
+		ttl:     tokenVersionTTL,
+		now:     time.Now,
+		entries: make(map[uuid.UUID]tokenVersionEntry),
+	}
+}
+
+// get returns userID's token version, or sql.ErrNoRows if the user no
+// longer exists.
+func (c *tokenVersionCache) get(ctx context.Context, userID uuid.UUID) (int32, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.version, nil
+	}
+
+	version, err := c.db.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	c.set(userID, version)
+	return version, nil
+}
+
+// bump revokes every access token userID holds by moving them to a new
+// token version, which it returns.
+func (c *tokenVersionCache) bump(ctx context.Context, userID uuid.UUID) (int32, error) {
+	version, err := c.db.IncrementTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	c.set(userID, version)
+	return version, nil
+}
+
+func (c *tokenVersionCache) set(userID uuid.UUID, version int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCachedTokenVersions {
+		clear(c.entries)
+	}
+	c.entries[userID] = tokenVersionEntry{version: version, fetchedAt: c.now()}
+}
+
+// reset forgets every cached version, for when users have been deleted.
+func (c *tokenVersionCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// makeAccessToken issues tok at its user's current token version.
+func (apiCfg *apiConfig) makeAccessToken(ctx context.Context, tok auth.AccessToken, expiresIn time.Duration) (string, error) {
+	version, err := apiCfg.tokenVersions.get(ctx, tok.UserID)
+	if err != nil {
+		return "", err
+	}
+	tok.Version = version
+	return apiCfg.keys.MakeAccessToken(tok, expiresIn)
+}
+
+// parseAccessToken checks an access token's signature and expiry, and that
+// it has not been revoked since it was issued: by its user's token version
+// moving on, or by the user being deleted. It also takes user API keys,
+// which stand in for an access token with the key's scopes.
+func (apiCfg *apiConfig) parseAccessToken(ctx context.Context, token string) (auth.AccessToken, error) {
+	if auth.IsAPIKey(token) {
+		return apiCfg.parseAPIKey(ctx, token)
+	}
+
+	tok, err := apiCfg.keys.ParseAccessToken(token)
+	if err != nil {
+		return auth.AccessToken{}, err
+	}
+
+	version, err := apiCfg.tokenVersions.get(ctx, tok.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.AccessToken{}, errTokenRevoked
+	}
+	if err != nil {
+		return auth.AccessToken{}, err
+	}
+	if tok.Version < version {
+		return auth.AccessToken{}, errTokenRevoked
+	}
+	return tok, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/memstore"
+)
+
+func TestPasswordChangeRevokesTokens(t *testing.T) {
+	_, srv := newTestServer(t)
+	first := createAndLogin(t, srv, "walt@example.com", "04234")
+	var second loginResponse
+	doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "walt@example.com", "password": "04234"}, &second)
+
+	// Changing only the email keeps every token.
+	var updated struct {
+		Token string `json:"token"`
+	}
+	resp := doJSON(t, srv, "PUT", "/api/users", "Bearer "+first.Token, map[string]string{"email": "heisenberg@example.com", "password": "04234"}, &updated)
+	if resp.StatusCode != http.StatusOK || updated.Token != first.Token {
+		t.Fatalf("PUT /api/users with the same password status = %d, token changed = %v", resp.StatusCode, updated.Token != first.Token)
+	}
+
+	resp = doJSON(t, srv, "PUT", "/api/users", "Bearer "+first.Token, map[string]string{"email": "heisenberg@example.com", "password": "bluesky99"}, &updated)
+	if resp.StatusCode != http.StatusOK || updated.Token == "" || updated.Token == first.Token {
+		t.Fatalf("PUT /api/users with a new password status = %d, want 200 and a new token", resp.StatusCode)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{name: "Token used to change the password", token: first.Token, wantCode: http.StatusUnauthorized},
+		{name: "Token from another session", token: second.Token, wantCode: http.StatusUnauthorized},
+		{name: "Token from the response", token: updated.Token, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doJSON(t, srv, "GET", "/api/timeline", "Bearer "+tt.token, nil, nil); resp.StatusCode != tt.wantCode {
+				t.Errorf("GET /api/timeline status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	if code, _ := refresh(t, srv, second.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh for another session status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	code, refreshed := refresh(t, srv, first.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("POST /api/refresh for the session that changed the password status = %d, want %d", code, http.StatusOK)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/timeline", "Bearer "+refreshed.Token, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /api/timeline with a refreshed token status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestDeletedUserTokenRevoked(t *testing.T) {
+	_, srv := newTestServer(t)
+	login := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	if resp := doJSON(t, srv, "GET", "/api/timeline", "Bearer "+login.Token, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/timeline status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp := doJSON(t, srv, "POST", "/admin/reset", "", nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /admin/reset status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/timeline", "Bearer "+login.Token, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /api/timeline for a deleted user status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+// versionCountingStore counts token version lookups.
+type versionCountingStore struct {
+	*memstore.Store
+	lookups int
+}
+
+func (s *versionCountingStore) GetTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	s.lookups++
+	return s.Store.GetTokenVersion(ctx, id)
+}
+
+func TestTokenVersionCache(t *testing.T) {
+	ctx := context.Background()
+	db := &versionCountingStore{Store: memstore.New()}
+	user, _ := db.CreateUser(ctx, database.CreateUserParams{Email: "walt@example.com", HashedPassword: "x", Handle: "walt"})
+	now := time.Now()
+	cache := newTokenVersionCache(db)
+	cache.now = func() time.Time { return now }
+
+	tests := []struct {
+		name        string
+		advance     time.Duration
+		bump        bool
+		wantVersion int32
+		wantLookups int
+	}{
+		{name: "First lookup", wantVersion: 0, wantLookups: 1},
+		{name: "Cached", advance: time.Second, wantVersion: 0, wantLookups: 1},
+		{name: "Bump updates the cache", bump: true, wantVersion: 1, wantLookups: 1},
+		{name: "Expired", advance: tokenVersionTTL, wantVersion: 1, wantLookups: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if tt.bump {
+				if _, err := cache.bump(ctx, user.ID); err != nil {
+					t.Fatalf("bump() error = %v", err)
+				}
+			}
+			got, err := cache.get(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+			if got != tt.wantVersion || db.lookups != tt.wantLookups {
+				t.Errorf("get() = %d after %d lookups, want %d after %d", got, db.lookups, tt.wantVersion, tt.wantLookups)
+			}
+		})
+	}
+}
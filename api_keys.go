@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+const (
+	// apiKeyTouchInterval is how stale a key's last_used_at may get before
+	// using the key updates it.
+	apiKeyTouchInterval = time.Minute
+	maxAPIKeyNameLength = 100
+)
+
+var errUnknownAPIKey = errors.New("unknown API key")
+
+// parseAPIKey looks up a user API key and returns the access token it
+// stands in for: one for its user with its scopes, and no session.
+func (apiCfg *apiConfig) parseAPIKey(ctx context.Context, key string) (auth.AccessToken, error) {
+	row, err := apiCfg.db.GetApiKeyByHash(ctx, auth.HashAPIKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.AccessToken{}, errUnknownAPIKey
+	}
+	if err != nil {
+		return auth.AccessToken{}, err
+	}
+	scopes, err := auth.ParseScopes(row.Scope)
+	if err != nil {
+		return auth.AccessToken{}, err
+	}
+
+	if !row.LastUsedAt.Valid || time.Since(row.LastUsedAt.Time) >= apiKeyTouchInterval {
+		if err := apiCfg.db.TouchApiKey(ctx, row.ID); err != nil {
+			// The key still works; only its last_used_at is behind.
+			log.Printf("Could not record use of API key %s: %s", row.ID, err)
+		}
+	}
+
+	return auth.AccessToken{
+		ID:     row.ID.String(),
+		UserID: row.UserID,
+		Scopes: scopes,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// Chirp kinds, as stored in chirps.kind.
+const (
+	chirpKindChirp   = "chirp"
+	chirpKindRechirp = "rechirp"
+	chirpKindQuote   = "quote"
+)
+
+type ChirpResponse struct {
+	ID           uuid.UUID      `json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Body         string         `json:"body"`
+	UserID       uuid.UUID      `json:"user_id"`
+	InReplyTo    *uuid.UUID     `json:"in_reply_to"`
+	Kind         string         `json:"kind"`
+	Original     *ChirpResponse `json:"original"`
+	LikeCount    int64          `json:"like_count"`
+	LikedByMe    bool           `json:"liked_by_me"`
+	RechirpCount int64          `json:"rechirp_count"`
+	Entities     ChirpEntities  `json:"entities"`
+}
+
+// ChirpEntities marks up parts of a chirp's body.
+type ChirpEntities struct {
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+// MentionEntity is an @handle in a chirp body that names a user. Start and
+// End are offsets into the body in Unicode code points, End exclusive.
+type MentionEntity struct {
+	Start  int       `json:"start"`
+	End    int       `json:"end"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// newChirpResponse renders a chirp on its own, without engagement counts or
+// the chirp it reposts. Use chirpResponses for anything already published.
+func newChirpResponse(chirp database.Chirp) ChirpResponse {
+	resp := ChirpResponse{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		Kind:      chirp.Kind,
+		Entities:  ChirpEntities{Mentions: []MentionEntity{}},
+	}
+	if chirp.InReplyTo.Valid {
+		resp.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	return resp
+}
+
+// chirpResponses renders chirps with the chirps they rechirp or quote
+// embedded as original, plus like and rechirp counts, whether viewer liked
+// them and their mentions. Everything is loaded for the whole batch at once, so the
+// number of queries does not grow with len(chirps). Originals are embedded
+// one level deep: a quoted quote comes without its own original.
+func (apiCfg *apiConfig) chirpResponses(ctx context.Context, chirps []database.Chirp, viewer uuid.NullUUID) ([]ChirpResponse, error) {
+	resps := make([]ChirpResponse, 0, len(chirps))
+	if len(chirps) == 0 {
+		return resps, nil
+	}
+
+	var refIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.RefChirpID.Valid {
+			refIDs = append(refIDs, chirp.RefChirpID.UUID)
+		}
+	}
+	var originals []database.Chirp
+	if len(refIDs) > 0 {
+		var err error
+		originals, err = apiCfg.db.GetChirpsByIds(ctx, refIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(chirps)+len(originals))
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+	for _, original := range originals {
+		ids = append(ids, original.ID)
+	}
+
+	stats, err := apiCfg.db.GetChirpLikeStats(ctx, database.GetChirpLikeStatsParams{
+		ViewerID: viewer,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	likes := make(map[uuid.UUID]database.GetChirpLikeStatsRow, len(stats))
+	for _, row := range stats {
+		likes[row.ChirpID] = row
+	}
+
+	counts, err := apiCfg.db.GetRechirpCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	rechirps := make(map[uuid.UUID]int64, len(counts))
+	for _, row := range counts {
+		rechirps[row.RefChirpID.UUID] = row.RechirpCount
+	}
+
+	mentionRows, err := apiCfg.db.GetChirpMentions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mentions := make(map[uuid.UUID][]MentionEntity)
+	for _, m := range mentionRows {
+		mentions[m.ChirpID] = append(mentions[m.ChirpID], MentionEntity{
+			Start:  int(m.StartOffset),
+			End:    int(m.EndOffset),
+			UserID: m.UserID,
+		})
+	}
+
+	render := func(chirp database.Chirp) ChirpResponse {
+		resp := newChirpResponse(chirp)
+		resp.LikeCount = likes[chirp.ID].LikeCount
+		resp.LikedByMe = likes[chirp.ID].LikedByMe
+		resp.RechirpCount = rechirps[chirp.ID]
+		if m, ok := mentions[chirp.ID]; ok {
+			resp.Entities.Mentions = m
+		}
+		return resp
+	}
+
+	byID := make(map[uuid.UUID]ChirpResponse, len(originals))
+	for _, original := range originals {
+		byID[original.ID] = render(original)
+	}
+
+	for _, chirp := range chirps {
+		resp := render(chirp)
+		if original, ok := byID[chirp.RefChirpID.UUID]; ok && chirp.RefChirpID.Valid {
+			resp.Original = &original
+		}
+		resps = append(resps, resp)
+	}
+	return resps, nil
+}
+
+// viewerID returns the user making a request on an endpoint that works with
+// or without a session. Requests without a valid access token are treated as
+// anonymous rather than rejected.
+func (apiCfg *apiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	tok, ok := principalFrom(r.Context())
+	if !ok {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: tok.UserID, Valid: true}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// runCommand runs one of the maintenance commands instead of the server:
+//
+//	chirpy rotate-keys [-algorithm EdDSA|RS256]
+func runCommand(args []string) error {
+	switch args[0] {
+	case "rotate-keys":
+		return runRotateKeys(args[1:])
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// runRotateKeys makes a new access token signing key in the database.
+// Running servers start signing with it when they next reload their keys.
+func runRotateKeys(args []string) error {
+	flags := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	algorithm := flags.String("algorithm", "", "algorithm for the new key, EdDSA or RS256 (default $JWT_ALGORITHM or EdDSA)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		return errors.New("rotate-keys needs DB_URL to be set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return fmt.Errorf("database failed to open: %w", err)
+	}
+	defer db.Close()
+
+	keys, err := newKeyring(database.New(db), os.Getenv("PLATFORM"))
+	if err != nil {
+		return err
+	}
+	key, err := keys.Rotate(context.Background(), *algorithm)
+	if err != nil {
+		return fmt.Errorf("rotating signing key: %w", err)
+	}
+
+	fmt.Printf("Now signing with %s (%s); running servers switch within %s.\n", key.ID, key.Algorithm, signingKeyReloadInterval)
+	for _, old := range keys.Keys() {
+		if old.ID != key.ID {
+			fmt.Printf("%s (%s) is accepted until %s.\n", old.ID, old.Algorithm, old.RetiresAt.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// ApiKeyResponse describes a user API key. Key is only set in the response
+// that creates it; after that, Prefix is all there is to recognise it by.
+type ApiKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func newApiKeyResponse(key database.ApiKey) ApiKeyResponse {
+	resp := ApiKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scope:     key.Scope,
+		CreatedAt: key.CreatedAt,
+	}
+	if key.LastUsedAt.Valid {
+		resp.LastUsedAt = &key.LastUsedAt.Time
+	}
+	return resp
+}
+
+// handlerCreateAPIKey makes a long-lived key for {"name": ..., "scope": ...}
+// that can be sent as "Authorization: ApiKey <key>" wherever an access
+// token is accepted. It cannot have scopes the requester's token lacks, nor
+// admin, which only the ADMIN_KEY and admin tokens carry.
+func (apiCfg *apiConfig) handlerCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+
+	var req struct {
+		Name  string `json:"name"`
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		respondWithError(w, http.StatusBadRequest, "name must be 1 to 100 characters")
+		return
+	}
+	scopes, err := auth.ParseScopes(req.Scope)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(scopes) == 0 {
+		respondWithError(w, http.StatusBadRequest, "scope is required")
+		return
+	}
+	if auth.HasScope(scopes, auth.ScopeAdmin) {
+		respondWithProblem(w, http.StatusForbidden, "A key cannot have the admin scope")
+		return
+	}
+	if !auth.HasScopes(tok.Scopes, scopes) {
+		respondWithProblem(w, http.StatusForbidden, "A key cannot have scopes your token does not have")
+		return
+	}
+
+	key, prefix := auth.MakeAPIKey()
+	row, err := apiCfg.db.CreateApiKey(r.Context(), database.CreateApiKeyParams{
+		UserID:  tok.UserID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: auth.HashAPIKey(key),
+		Scope:   strings.Join(scopes, " "),
+	})
+	if err != nil {
+		log.Printf("Error creating API key: %s", err)
+		respondWithError(w, 500, "Error creating API key")
+		return
+	}
+
+	resp := newApiKeyResponse(row)
+	resp.Key = key
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+// handlerListAPIKeys lists the requester's API keys, newest first.
+func (apiCfg *apiConfig) handlerListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+
+	keys, err := apiCfg.db.ListApiKeys(r.Context(), tok.UserID)
+	if err != nil {
+		log.Printf("Error listing API keys: %s", err)
+		respondWithError(w, 500, "Error getting API keys")
+		return
+	}
+
+	resps := make([]ApiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resps = append(resps, newApiKeyResponse(key))
+	}
+	respondWithJSON(w, 200, resps)
+}
+
+// handlerDeleteAPIKey revokes one of the requester's API keys.
+func (apiCfg *apiConfig) handlerDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+
+	keyID, err := uuid.Parse(r.PathValue("keyID"))
+	if err != nil {
+		respondWithError(w, 400, "keyID is not a valid id")
+		return
+	}
+
+	n, err := apiCfg.db.DeleteApiKey(r.Context(), database.DeleteApiKeyParams{
+		ID:     keyID,
+		UserID: tok.UserID,
+	})
+	if err != nil {
+		log.Printf("Error deleting API key %s: %s", keyID, err)
+		respondWithError(w, 500, "Error deleting API key")
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "API key not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
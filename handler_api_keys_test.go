@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "yo1234")
+
+	var bot ApiKeyResponse
+	resp := doJSON(t, srv, "POST", "/api/keys", "Bearer "+walt.Token, map[string]string{"name": "bot", "scope": "chirps:read chirps:write"}, &bot)
+	if resp.StatusCode != http.StatusCreated || !strings.HasPrefix(bot.Key, bot.Prefix) || bot.Prefix == bot.Key {
+		t.Fatalf("POST /api/keys = %d %+v, want 201 with a key starting with its prefix", resp.StatusCode, bot)
+	}
+	var reader ApiKeyResponse
+	doJSON(t, srv, "POST", "/api/keys", "Bearer "+walt.Token, map[string]string{"name": "reader", "scope": "chirps:read"}, &reader)
+	var admin TokenResponse
+	doJSON(t, srv, "POST", "/admin/tokens", adminAuth, map[string]any{"user_id": walt.ID, "scope": "admin account:write"}, &admin)
+
+	createTests := []struct {
+		name          string
+		authorization string
+		body          map[string]string
+		wantCode      int
+	}{
+		{name: "No name", authorization: "Bearer " + walt.Token, body: map[string]string{"scope": "chirps:read"}, wantCode: http.StatusBadRequest},
+		{name: "No scope", authorization: "Bearer " + walt.Token, body: map[string]string{"name": "x"}, wantCode: http.StatusBadRequest},
+		{name: "Admin scope from a user token", authorization: "Bearer " + walt.Token, body: map[string]string{"name": "x", "scope": "admin"}, wantCode: http.StatusForbidden},
+		{name: "Admin scope from an admin token", authorization: "Bearer " + admin.Token, body: map[string]string{"name": "x", "scope": "admin"}, wantCode: http.StatusForbidden},
+		{name: "Key without account:write", authorization: "ApiKey " + bot.Key, body: map[string]string{"name": "x", "scope": "chirps:read"}, wantCode: http.StatusForbidden},
+	}
+	for _, tt := range createTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doJSON(t, srv, "POST", "/api/keys", tt.authorization, tt.body, nil); resp.StatusCode != tt.wantCode {
+				t.Errorf("POST /api/keys status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	useTests := []struct {
+		name          string
+		authorization string
+		method        string
+		path          string
+		body          any
+		wantCode      int
+	}{
+		{name: "Key chirps", authorization: "ApiKey " + bot.Key, method: "POST", path: "/api/chirps", body: map[string]string{"body": "beep"}, wantCode: http.StatusCreated},
+		{name: "Key reads the timeline", authorization: "ApiKey " + bot.Key, method: "GET", path: "/api/timeline", wantCode: http.StatusOK},
+		{name: "Read-only key cannot chirp", authorization: "ApiKey " + reader.Key, method: "POST", path: "/api/chirps", body: map[string]string{"body": "beep"}, wantCode: http.StatusForbidden},
+		{name: "Unknown key", authorization: "ApiKey chirpy_nope", method: "GET", path: "/api/timeline", wantCode: http.StatusUnauthorized},
+		{name: "User key on the admin API", authorization: "ApiKey " + bot.Key, method: "GET", path: "/admin/keys", wantCode: http.StatusForbidden},
+	}
+	for _, tt := range useTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doJSON(t, srv, tt.method, tt.path, tt.authorization, tt.body, nil); resp.StatusCode != tt.wantCode {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	var keys []ApiKeyResponse
+	doJSON(t, srv, "GET", "/api/keys", "Bearer "+walt.Token, nil, &keys)
+	if len(keys) != 2 || keys[0].ID != reader.ID || keys[1].ID != bot.ID {
+		t.Fatalf("GET /api/keys = %+v, want reader then bot", keys)
+	}
+	if keys[1].Key != "" || keys[1].LastUsedAt == nil {
+		t.Errorf("GET /api/keys = %+v, want bot without its key and with a last_used_at", keys)
+	}
+
+	if resp := doJSON(t, srv, "DELETE", "/api/keys/"+bot.ID.String(), "Bearer "+jesse.Token, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("DELETE another user's key status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := doJSON(t, srv, "DELETE", "/api/keys/"+bot.ID.String(), "Bearer "+walt.Token, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE /api/keys/{keyID} status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/timeline", "ApiKey "+bot.Key, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /api/timeline with a deleted key status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	// Keys go when their user changes their password or signs out
+	// everywhere.
+	revokeTests := []struct {
+		name   string
+		email  string
+		method string
+		path   string
+		body   any
+	}{
+		{name: "Revoke all sessions", email: "skyler@example.com", method: "POST", path: "/api/sessions/revoke-all"},
+		{name: "Password changed with PUT", email: "hank@example.com", method: "PUT", path: "/api/users", body: map[string]string{"email": "hank@example.com", "password": "fghij"}},
+		{name: "Password changed with PATCH", email: "marie@example.com", method: "PATCH", path: "/api/users", body: map[string]string{"password": "fghij", "current_password": "abcde"}},
+	}
+	for _, tt := range revokeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := createAndLogin(t, srv, tt.email, "abcde")
+			var key ApiKeyResponse
+			doJSON(t, srv, "POST", "/api/keys", "Bearer "+user.Token, map[string]string{"name": "bot", "scope": "chirps:read"}, &key)
+			if resp := doJSON(t, srv, tt.method, tt.path, "Bearer "+user.Token, tt.body, nil); resp.StatusCode >= 300 {
+				t.Fatalf("%s %s status = %d, want success", tt.method, tt.path, resp.StatusCode)
+			}
+			if resp := doJSON(t, srv, "GET", "/api/timeline", "ApiKey "+key.Key, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("GET /api/timeline with the key afterwards status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+			}
+		})
+	}
+}
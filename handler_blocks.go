@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// handlerBlockUser blocks {userID} for the requester. Blocking removes the
+// follows between the two users, deletes the blocked user's rechirps of the
+// requester's chirps and detaches their quotes of them, which then render
+// with a null original as if the quoted chirp had been deleted. While the
+// block stands, the blocked user cannot follow, rechirp or quote the
+// requester.
+func (apiCfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	blockerID, blockedID, ok := apiCfg.otherUserTarget(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := apiCfg.db.GetUserById(ctx, blockedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "User could not be found.")
+			return
+		}
+		log.Printf("Error looking up user to block: %s", err)
+		respondWithError(w, 500, "Error blocking user")
+		return
+	}
+
+	err = apiCfg.db.BlockUser(ctx, database.BlockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		log.Printf("Error blocking user: %s", err)
+		respondWithError(w, 500, "Error blocking user")
+		return
+	}
+
+	err = apiCfg.db.UnfollowUser(ctx, database.UnfollowUserParams{FollowerID: blockerID, FolloweeID: blockedID})
+	if err == nil {
+		err = apiCfg.db.UnfollowUser(ctx, database.UnfollowUserParams{FollowerID: blockedID, FolloweeID: blockerID})
+	}
+	if err == nil {
+		err = apiCfg.db.DeleteBlockedRechirps(ctx, database.DeleteBlockedRechirpsParams{BlockedID: blockedID, BlockerID: blockerID})
+	}
+	if err == nil {
+		err = apiCfg.db.DetachBlockedQuotes(ctx, database.DetachBlockedQuotesParams{BlockedID: blockedID, BlockerID: blockerID})
+	}
+	if err != nil {
+		// The block itself is in place; retrying the request finishes the
+		// cleanup.
+		log.Printf("Error cleaning up after block: %s", err)
+		respondWithError(w, 500, "Error blocking user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (apiCfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, blockedID, ok := apiCfg.otherUserTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := apiCfg.db.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		log.Printf("Error unblocking user: %s", err)
+		respondWithError(w, 500, "Error unblocking user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
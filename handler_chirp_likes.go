@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// handlerLikeChirp likes a chirp for the requester. Liking a chirp twice is
+// not an error.
+func (apiCfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := requestPrincipal(r).UserID
+
+	chirpID, ok := parseChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	chirp, err := apiCfg.db.GetChirpById(ctx, chirpID)
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found.")
+		return
+	}
+
+	liked, err := apiCfg.db.LikeChirp(ctx, database.LikeChirpParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+	})
+	if isForeignKeyViolation(err, "chirp_likes_chirp_id_fkey") {
+		respondWithError(w, 404, "Chirp not found.")
+		return
+	}
+	if err != nil {
+		log.Printf("Error liking chirp: %s", err)
+		respondWithError(w, 500, "Error liking chirp")
+		return
+	}
+	if liked > 0 {
+		apiCfg.notify(ctx, chirp.UserID, userID, notificationKindLike, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnlikeChirp removes the requester's like, if there is one.
+func (apiCfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+
+	chirpID, ok := parseChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	err := apiCfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+	})
+	if err != nil {
+		log.Printf("Error unliking chirp: %s", err)
+		respondWithError(w, 500, "Error unliking chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// countingStore counts like-stat lookups so tests can check list handlers
+// load them once per request rather than once per chirp.
+type countingStore struct {
+	database.Store
+	statCalls int
+}
+
+func (s *countingStore) GetChirpLikeStats(ctx context.Context, arg database.GetChirpLikeStatsParams) ([]database.GetChirpLikeStatsRow, error) {
+	s.statCalls++
+	return s.Store.GetChirpLikeStats(ctx, arg)
+}
+
+func TestLikes(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	store := &countingStore{Store: apiCfg.db}
+	apiCfg.db = store
+
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+
+	var chirps []ChirpResponse
+	for _, body := range []string{"one", "two", "three"} {
+		var chirp ChirpResponse
+		doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": body}, &chirp)
+		chirps = append(chirps, chirp)
+	}
+	likePath := "/api/chirps/" + chirps[0].ID.String() + "/like"
+
+	for _, token := range []string{walt.Token, jesse.Token, jesse.Token} {
+		if resp := doJSON(t, srv, "PUT", likePath, "Bearer "+token, nil, nil); resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("PUT %s status = %d, want %d", likePath, resp.StatusCode, http.StatusNoContent)
+		}
+	}
+	if resp := doJSON(t, srv, "PUT", likePath, "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("PUT like without token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	var got ChirpResponse
+	doJSON(t, srv, "GET", "/api/chirps/"+chirps[0].ID.String(), "Bearer "+jesse.Token, nil, &got)
+	if got.LikeCount != 2 || !got.LikedByMe {
+		t.Errorf("like_count = %d, liked_by_me = %v, want 2, true", got.LikeCount, got.LikedByMe)
+	}
+
+	doJSON(t, srv, "DELETE", likePath, "Bearer "+jesse.Token, nil, nil)
+	doJSON(t, srv, "DELETE", likePath, "Bearer "+jesse.Token, nil, nil)
+
+	store.statCalls = 0
+	var list []ChirpResponse
+	doJSON(t, srv, "GET", "/api/chirps", "Bearer "+jesse.Token, nil, &list)
+	if store.statCalls != 1 {
+		t.Errorf("GET /api/chirps loaded like stats %d times, want 1", store.statCalls)
+	}
+	if len(list) != 3 || list[0].LikeCount != 1 || list[0].LikedByMe || list[1].LikeCount != 0 {
+		t.Errorf("GET /api/chirps = %+v, want first chirp liked once, not by jesse", list)
+	}
+}
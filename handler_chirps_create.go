@@ -1,53 +1,27 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
-	"github.com/mrbaker1917/chirpy/internal/auth"
 	"github.com/mrbaker1917/chirpy/internal/database"
 )
 
-// struct:
-
-type ChirpResponse struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
-}
-
 // helpers:
 
-func sanitizeChirp(s string) string {
-	profane_words := []string{"kerfuffle", "sharbert", "fornax"}
-	s_slice := strings.Split(s, " ")
-	for i, w := range s_slice {
-		for _, p := range profane_words {
-			if strings.ToLower(w) == p {
-				s_slice[i] = "****"
-			}
-		}
-	}
-	new_str := strings.Join(s_slice, " ")
-
-	return new_str
-}
-
-func validateChirp(s string) (string, error) {
+func validateChirp(s string) error {
 
 	if len(s) > 140 {
-		return "", fmt.Errorf("Chirp is too long!")
+		return fmt.Errorf("Chirp is too long!")
 	}
 
-	cleaned_str := sanitizeChirp(s)
-	return cleaned_str, nil
+	return nil
 }
 
 // handler:
@@ -55,7 +29,9 @@ func (apiCfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Reque
 	ctx := r.Context()
 
 	type reqChirp struct {
-		Body string `json:"body"`
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+		QuoteOf   *uuid.UUID `json:"quote_of"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -67,43 +43,136 @@ func (apiCfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, 401, "Requester does not have a session token")
+	userID := requestPrincipal(r).UserID
+
+	if err := validateChirp(chp.Body); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	userID, err := auth.ValidateJWT(bearerToken, apiCfg.secret)
-	if err != nil {
-		respondWithError(w, 401, "Session token not valid!")
+	// A quote without a body would be a second rechirp of the same chirp.
+	if chp.QuoteOf != nil && strings.TrimSpace(chp.Body) == "" {
+		respondWithError(w, http.StatusBadRequest, "A quote needs a body; rechirp the chirp instead")
 		return
 	}
 
-	cleanedBody, err := validateChirp(chp.Body)
+	verdict, err := apiCfg.moderator.Moderate(ctx, chp.Body)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+		log.Printf("Could not moderate chirp: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "Could not create chirp")
 		return
 	}
+	if verdict.Rejected {
+		respondWithError(w, http.StatusBadRequest, "Chirp contains words that are not allowed")
+		return
+	}
+	cleanedBody := verdict.Body
+
+	var inReplyTo uuid.NullUUID
+	var parent database.Chirp
+	if chp.InReplyTo != nil {
+		parent, err = apiCfg.db.GetChirpById(ctx, *chp.InReplyTo)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "The chirp you are replying to does not exist")
+			return
+		}
+		if err != nil {
+			log.Printf("Could not look up parent chirp: %s", err)
+			respondWithError(w, http.StatusInternalServerError, "Could not create chirp")
+			return
+		}
+		inReplyTo = uuid.NullUUID{UUID: *chp.InReplyTo, Valid: true}
+	}
+
+	kind := chirpKindChirp
+	var quoteOf uuid.NullUUID
+	if chp.QuoteOf != nil {
+		original, err := apiCfg.repostTarget(ctx, *chp.QuoteOf, userID)
+		if errors.Is(err, errChirpNotFound) {
+			respondWithError(w, http.StatusBadRequest, "The chirp you are quoting does not exist")
+			return
+		}
+		if errors.Is(err, errRepostBlocked) {
+			respondWithError(w, http.StatusForbidden, "You cannot quote this chirp")
+			return
+		}
+		if err != nil {
+			log.Printf("Could not look up quoted chirp: %s", err)
+			respondWithError(w, http.StatusInternalServerError, "Could not create chirp")
+			return
+		}
+		kind = chirpKindQuote
+		quoteOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
 
 	chirp, err := apiCfg.db.CreateChirp(
 		ctx,
 		database.CreateChirpParams{
-			Body:   cleanedBody,
-			UserID: userID,
+			Body:       cleanedBody,
+			UserID:     userID,
+			InReplyTo:  inReplyTo,
+			Kind:       kind,
+			RefChirpID: quoteOf,
 		},
 	)
 
+	if isForeignKeyViolation(err, "chirps_in_reply_to_fkey") {
+		// The parent was deleted after we looked it up.
+		respondWithError(w, http.StatusBadRequest, "The chirp you are replying to does not exist")
+		return
+	}
+	if isForeignKeyViolation(err, "chirps_ref_chirp_id_fkey") {
+		respondWithError(w, http.StatusBadRequest, "The chirp you are quoting does not exist")
+		return
+	}
 	if err != nil {
 		log.Printf("Could not create chirp: %s", err)
 		respondWithError(w, http.StatusInternalServerError, "Could not create chirp")
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, ChirpResponse{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	if verdict.Flagged {
+		err := apiCfg.db.FlagChirp(ctx, database.FlagChirpParams{
+			ChirpID: chirp.ID,
+			Words:   verdict.Matches,
+		})
+		if err != nil {
+			log.Printf("Could not flag chirp %s: %s", chirp.ID, err)
+		}
+	}
+
+	if tags := extractHashtags(cleanedBody); len(tags) > 0 {
+		err := apiCfg.db.AddChirpHashtags(ctx, database.AddChirpHashtagsParams{
+			Tags:    tags,
+			ChirpID: chirp.ID,
+		})
+		if err != nil {
+			// The chirp is up; it just won't show on its hashtag pages.
+			log.Printf("Could not save hashtags for chirp %s: %s", chirp.ID, err)
+		}
+	}
+
+	// Someone mentioned in a reply to their own chirp gets just the reply
+	// notification.
+	chirpRef := uuid.NullUUID{UUID: chirp.ID, Valid: true}
+	if inReplyTo.Valid {
+		apiCfg.notify(ctx, parent.UserID, userID, notificationKindReply, chirpRef)
+	}
+	mentioned, err := apiCfg.saveMentions(ctx, chirp)
+	if err != nil {
+		log.Printf("Could not save mentions for chirp %s: %s", chirp.ID, err)
+	}
+	for _, mentionedID := range mentioned {
+		if !inReplyTo.Valid || mentionedID != parent.UserID {
+			apiCfg.notify(ctx, mentionedID, userID, notificationKindMention, chirpRef)
+		}
+	}
+
+	resps, err := apiCfg.chirpResponses(ctx, []database.Chirp{chirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Could not render chirp: %s", err)
+		respondWithJSON(w, http.StatusCreated, newChirpResponse(chirp))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, resps[0])
 }
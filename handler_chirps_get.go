@@ -3,7 +3,6 @@ package main
 import (
 	"log"
 	"net/http"
-	"sort"
 
 	"github.com/google/uuid"
 	"github.com/mrbaker1917/chirpy/internal/database"
@@ -12,78 +11,93 @@ import (
 func (apiCfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	chirps := []database.Chirp{}
-	var err error
-	authorID := r.URL.Query().Get("author_id")
-	if authorID != "" {
-		uAuthorID, err := uuid.Parse(authorID)
+	page, err := parsePageParams(r.URL.Query(), false)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var authorID uuid.NullUUID
+	if a := r.URL.Query().Get("author_id"); a != "" {
+		uAuthorID, err := uuid.Parse(a)
 		if err != nil {
 			log.Printf("We encountered an error parsing authorID: %s", err)
-			respondWithError(w, 501, "we encountered an error parsing author_id")
+			respondWithError(w, http.StatusBadRequest, "we encountered an error parsing author_id")
 			return
 		}
-		chirps, err = apiCfg.db.GetChirpsByAuthor(ctx, uAuthorID)
-		if err != nil {
-			log.Printf("We encountered grabbing chirps by this authorID: %s", err)
-			respondWithError(w, 501, "We could not find any chirps for this author_id")
-			return
-		}
-
-	} else {
+		authorID = uuid.NullUUID{UUID: uAuthorID, Valid: true}
+	}
 
-		chirps, err = apiCfg.db.GetChirps(ctx)
-		if err != nil {
-			log.Printf("we encountered an error: %s", err)
-			respondWithError(w, 501, "we encountered an error getting chirps")
-			return
-		}
+	cursorCreatedAt, cursorID := page.cursorArgs()
 
+	var chirps []database.Chirp
+	if page.queryDesc() {
+		chirps, err = apiCfg.db.ListChirpsDesc(ctx, database.ListChirpsDescParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			Limit:           int32(page.Limit + 1),
+		})
+	} else {
+		chirps, err = apiCfg.db.ListChirpsAsc(ctx, database.ListChirpsAscParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			Limit:           int32(page.Limit + 1),
+		})
 	}
-
-	sortWay := r.URL.Query().Get("sort")
-	if sortWay != "" {
-		if sortWay == "desc" {
-			sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
-		}
+	if err != nil {
+		log.Printf("we encountered an error: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "we encountered an error getting chirps")
+		return
 	}
 
-	response_chirps := []ChirpResponse{}
-	for _, chirp := range chirps {
-		response_chirps = append(response_chirps, ChirpResponse{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
-		})
+	chirps = finishPage(w, r, page, chirps, chirpCursor)
+
+	response_chirps, err := apiCfg.chirpResponses(ctx, chirps, apiCfg.viewerID(r))
+	if err != nil {
+		log.Printf("we encountered an error loading likes: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "we encountered an error getting chirps")
+		return
 	}
 	respondWithJSON(w, 200, response_chirps)
 }
 
-func (apiCfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request) {
-	chirpId := r.PathValue("chirpID")
+func chirpCursor(c database.Chirp) pageCursor {
+	return pageCursor{CreatedAt: c.CreatedAt, ID: c.ID}
+}
 
+// parseChirpID parses the {chirpID} path value, writing a 400 response if it
+// is not a valid id.
+func parseChirpID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	chirpId := r.PathValue("chirpID")
 	uChirpId, err := uuid.Parse(chirpId)
 	if err != nil {
-		log.Fatalf("failed to parse UUID %q: %v", chirpId, err)
-		return
+		log.Printf("failed to parse UUID %q: %v", chirpId, err)
+		respondWithError(w, http.StatusBadRequest, "chirpID is not a valid id")
+		return uuid.Nil, false
 	}
-	if chirpId == "" {
-		log.Println("No ChirpID found. We need a ChirpID to get the Chirp.")
+	return uChirpId, true
+}
+
+func (apiCfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request) {
+	uChirpId, ok := parseChirpID(w, r)
+	if !ok {
 		return
 	}
 
 	chirp, err := apiCfg.db.GetChirpById(r.Context(), uChirpId)
 	if err != nil {
 		respondWithError(w, 404, "Chirp not found.")
+		return
 	}
 
-	respondWithJSON(w, 200, ChirpResponse{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	resps, err := apiCfg.chirpResponses(r.Context(), []database.Chirp{chirp}, apiCfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error loading chirp likes: %s", err)
+		respondWithError(w, 500, "Error getting chirp")
+		return
+	}
 
+	respondWithJSON(w, 200, resps[0])
 }
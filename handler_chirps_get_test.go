@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/memstore"
+)
+
+var linkRE = regexp.MustCompile(`<([^>]+)>; rel="(\w+)"`)
+
+// pageLinks parses a Link header into a map of rel to URL.
+func pageLinks(resp *http.Response) map[string]string {
+	links := map[string]string{}
+	for _, m := range linkRE.FindAllStringSubmatch(resp.Header.Get("Link"), -1) {
+		links[m[2]] = m[1]
+	}
+	return links
+}
+
+func chirpBodies(chirps []ChirpResponse) string {
+	var bodies []string
+	for _, c := range chirps {
+		bodies = append(bodies, c.Body)
+	}
+	return strings.Join(bodies, ",")
+}
+
+func TestGetChirpsPagination(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	apiCfg.db.(*memstore.Store).Now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+	for _, body := range []string{"a", "b", "c", "d", "e"} {
+		doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": body}, nil)
+	}
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]string{"body": "yo"}, nil)
+
+	tests := []struct {
+		name  string
+		path  string
+		pages []string
+	}{
+		{name: "Ascending", path: "/api/chirps?limit=2", pages: []string{"a,b", "c,d", "e,yo"}},
+		{name: "Descending", path: "/api/chirps?limit=4&sort=desc", pages: []string{"yo,e,d,c", "b,a"}},
+		{name: "By author", path: "/api/chirps?limit=2&author_id=" + walt.ID.String(), pages: []string{"a,b", "c,d", "e"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			var seen []string
+			for i := range tt.pages {
+				var chirps []ChirpResponse
+				resp := doJSON(t, srv, "GET", path, "", nil, &chirps)
+				seen = append(seen, chirpBodies(chirps))
+				next, ok := pageLinks(resp)["next"]
+				if i == len(tt.pages)-1 {
+					if ok {
+						t.Errorf("last page has a next link %q", next)
+					}
+					break
+				}
+				if !ok {
+					break
+				}
+				path = next
+			}
+			if strings.Join(seen, "|") != strings.Join(tt.pages, "|") {
+				t.Fatalf("pages = %v, want %v", seen, tt.pages)
+			}
+
+			// Walk back from the last page with prev links.
+			var chirps []ChirpResponse
+			for i := len(tt.pages) - 2; i >= 0; i-- {
+				resp := doJSON(t, srv, "GET", path, "", nil, nil)
+				prev, ok := pageLinks(resp)["prev"]
+				if !ok {
+					t.Fatalf("page %d has no prev link", i+1)
+				}
+				path = prev
+				doJSON(t, srv, "GET", path, "", nil, &chirps)
+				if got := chirpBodies(chirps); got != tt.pages[i] {
+					t.Errorf("prev page %d = %q, want %q", i, got, tt.pages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetChirpsBadParams(t *testing.T) {
+	_, srv := newTestServer(t)
+	for _, q := range []string{"limit=0", "limit=x", "sort=up", "cursor=nope", "author_id=nope"} {
+		if resp := doJSON(t, srv, "GET", "/api/chirps?"+q, "", nil, nil); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("GET /api/chirps?%s status = %d, want %d", q, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
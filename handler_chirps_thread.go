@@ -0,0 +1,159 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// Limits on how much of a conversation GET /api/chirps/{chirpID}/thread
+// loads below the requested chirp. Longer threads are cut off and reported
+// as truncated; clients page through the rest with the replies endpoint.
+const (
+	threadMaxDepth   = 50
+	threadMaxReplies = 500
+)
+
+type ThreadNode struct {
+	ChirpResponse
+	Replies []*ThreadNode `json:"replies"`
+}
+
+type ThreadResponse struct {
+	Ancestors []ChirpResponse `json:"ancestors"`
+	Chirp     *ThreadNode     `json:"chirp"`
+	Truncated bool            `json:"truncated"`
+}
+
+func (apiCfg *apiConfig) handlerGetReplies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	chirpID, ok := parseChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := parseFeedPageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := apiCfg.db.GetChirpById(ctx, chirpID); err != nil {
+		respondWithError(w, 404, "Chirp not found.")
+		return
+	}
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	replies, err := apiCfg.db.ListReplies(ctx, database.ListRepliesParams{
+		ChirpID:         chirpID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           int32(page.Limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error listing replies: %s", err)
+		respondWithError(w, 500, "Error listing replies")
+		return
+	}
+
+	replies = finishPage(w, r, page, replies, chirpCursor)
+
+	response_chirps, err := apiCfg.chirpResponses(ctx, replies, apiCfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error loading reply likes: %s", err)
+		respondWithError(w, 500, "Error listing replies")
+		return
+	}
+	respondWithJSON(w, 200, response_chirps)
+}
+
+// handlerGetThread returns a chirp with the chain of chirps it replies to,
+// root first, and the tree of replies below it, oldest first at each level.
+func (apiCfg *apiConfig) handlerGetThread(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	chirpID, ok := parseChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	chirp, err := apiCfg.db.GetChirpById(ctx, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "Chirp not found.")
+			return
+		}
+		log.Printf("Error getting chirp: %s", err)
+		respondWithError(w, 500, "Error getting thread")
+		return
+	}
+
+	ancestors, err := apiCfg.db.GetChirpAncestors(ctx, chirpID)
+	if err != nil {
+		log.Printf("Error getting thread ancestors: %s", err)
+		respondWithError(w, 500, "Error getting thread")
+		return
+	}
+
+	descendants, err := apiCfg.db.GetChirpDescendants(ctx, database.GetChirpDescendantsParams{
+		ChirpID:  chirpID,
+		MaxDepth: threadMaxDepth,
+		Limit:    threadMaxReplies + 1,
+	})
+	if err != nil {
+		log.Printf("Error getting thread replies: %s", err)
+		respondWithError(w, 500, "Error getting thread")
+		return
+	}
+
+	truncated := len(descendants) > threadMaxReplies
+	if truncated {
+		descendants = descendants[:threadMaxReplies]
+	}
+
+	// Render the whole thread in one batch: ancestors, the chirp, then its
+	// descendants.
+	all := make([]database.Chirp, 0, len(ancestors)+1+len(descendants))
+	all = append(all, ancestors...)
+	all = append(all, chirp)
+	all = append(all, descendants...)
+	rendered, err := apiCfg.chirpResponses(ctx, all, apiCfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error loading thread likes: %s", err)
+		respondWithError(w, 500, "Error getting thread")
+		return
+	}
+
+	root, complete := buildThreadTree(rendered[len(ancestors)], rendered[len(ancestors)+1:])
+
+	respondWithJSON(w, 200, ThreadResponse{
+		Ancestors: rendered[:len(ancestors)],
+		Chirp:     root,
+		Truncated: truncated || !complete,
+	})
+}
+
+// buildThreadTree nests descendants, which must be ordered oldest first,
+// under root. It reports complete=false if some replies had to be dropped
+// because their parent was not among the rows loaded.
+func buildThreadTree(root ChirpResponse, descendants []ChirpResponse) (*ThreadNode, bool) {
+	rootNode := &ThreadNode{ChirpResponse: root, Replies: []*ThreadNode{}}
+	nodes := map[uuid.UUID]*ThreadNode{root.ID: rootNode}
+	complete := true
+	for _, chirp := range descendants {
+		if chirp.InReplyTo == nil || nodes[*chirp.InReplyTo] == nil {
+			complete = false
+			continue
+		}
+		parent := nodes[*chirp.InReplyTo]
+		node := &ThreadNode{ChirpResponse: chirp, Replies: []*ThreadNode{}}
+		parent.Replies = append(parent.Replies, node)
+		nodes[chirp.ID] = node
+	}
+	return rootNode, complete
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestThreads(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	auth := "Bearer " + walt.Token
+
+	post := func(body string, inReplyTo *uuid.UUID) ChirpResponse {
+		t.Helper()
+		var chirp ChirpResponse
+		resp := doJSON(t, srv, "POST", "/api/chirps", auth, map[string]interface{}{"body": body, "in_reply_to": inReplyTo}, &chirp)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("POST /api/chirps %q status = %d, want %d", body, resp.StatusCode, http.StatusCreated)
+		}
+		return chirp
+	}
+
+	root := post("root", nil)
+	a := post("a", &root.ID)
+	post("b", &root.ID)
+	a1 := post("a1", &a.ID)
+
+	missing := uuid.New()
+	if resp := doJSON(t, srv, "POST", "/api/chirps", auth, map[string]interface{}{"body": "orphan", "in_reply_to": missing}, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("reply to missing chirp status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	var replies []ChirpResponse
+	doJSON(t, srv, "GET", "/api/chirps/"+root.ID.String()+"/replies", "", nil, &replies)
+	if got := chirpBodies(replies); got != "a,b" {
+		t.Errorf("replies = %q, want %q", got, "a,b")
+	}
+
+	var thread ThreadResponse
+	doJSON(t, srv, "GET", "/api/chirps/"+a.ID.String()+"/thread", "", nil, &thread)
+	if got := chirpBodies(thread.Ancestors); got != "root" {
+		t.Errorf("thread ancestors = %q, want %q", got, "root")
+	}
+	if thread.Chirp.ID != a.ID || len(thread.Chirp.Replies) != 1 || thread.Chirp.Replies[0].ID != a1.ID {
+		t.Errorf("thread of a = %+v, want a with reply a1", thread.Chirp)
+	}
+
+	doJSON(t, srv, "GET", "/api/chirps/"+root.ID.String()+"/thread", "", nil, &thread)
+	if n := len(thread.Chirp.Replies); n != 2 || len(thread.Chirp.Replies[0].Replies) != 1 {
+		t.Errorf("thread of root has %d replies, want a (with a1) and b", n)
+	}
+
+	// Deleting a detaches a1 instead of deleting it.
+	if resp := doJSON(t, srv, "DELETE", "/api/chirps/"+a.ID.String(), auth, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE a status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	var orphan ChirpResponse
+	if resp := doJSON(t, srv, "GET", "/api/chirps/"+a1.ID.String(), "", nil, &orphan); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET a1 after deleting a status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if orphan.InReplyTo != nil {
+		t.Errorf("a1 in_reply_to = %v, want null", orphan.InReplyTo)
+	}
+	doJSON(t, srv, "GET", "/api/chirps/"+root.ID.String()+"/thread", "", nil, &thread)
+	if n := len(thread.Chirp.Replies); n != 1 {
+		t.Errorf("thread of root after delete has %d replies, want 1", n)
+	}
+}
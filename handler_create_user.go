@@ -2,10 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
-	"github.com/mrbaker1917/chirpy/internal/auth"
 	"github.com/mrbaker1917/chirpy/internal/database"
 )
 
@@ -14,6 +14,7 @@ func (apiCfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Reques
 	type reqBody struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
+		Handle   string `json:"handle"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -24,29 +25,70 @@ func (apiCfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Reques
 		respondWithError(w, 500, "Error decoding request body")
 		return
 	}
+	if !validEmail(reqBdy.Email) {
+		respondWithFieldErrors(w, "The user is not valid", []fieldError{
+			{Field: "email", Code: "invalid", Message: "Must be an email address"},
+		})
+		return
+	}
+
+	// Users who don't pick a handle get one made from their email, with
+	// random digits added if it is already taken.
+	chosen := reqBdy.Handle != ""
+	handle := normalizeHandle(reqBdy.Handle)
+	if !chosen {
+		handle = handleFromEmail(reqBdy.Email)
+	}
+	if !validHandle(handle) {
+		respondWithError(w, 400, "Handles must be 1 to 15 letters, digits or underscores")
+		return
+	}
+
+	if !apiCfg.checkPassword(w, r, reqBdy.Password, reqBdy.Email, handle) {
+		return
+	}
 
-	hashed_password, err := auth.HashPassword(reqBdy.Password)
+	hashed_password, err := apiCfg.passwordParams.Hash(reqBdy.Password)
 	if err != nil {
-		log.Printf("Error hasing password: %s", err)
+		log.Printf("Error hashing password: %s", err)
+		respondWithError(w, 500, "Error creating user")
+		return
 	}
 
-	user, err := apiCfg.db.CreateUser(ctx, database.CreateUserParams{
-		Email:          reqBdy.Email,
-		HashedPassword: hashed_password,
-	})
+	var user database.User
+	for attempt := 0; ; attempt++ {
+		user, err = apiCfg.db.CreateUser(ctx, database.CreateUserParams{
+			Email:          reqBdy.Email,
+			HashedPassword: hashed_password,
+			Handle:         handle,
+		})
+		if !isUniqueViolation(err, "users_handle_key") || chosen || attempt == maxHandleAttempts {
+			break
+		}
+		handle = withHandleSuffix(handleFromEmail(reqBdy.Email))
+	}
 
+	if isUniqueViolation(err, "users_handle_key") {
+		respondWithError(w, http.StatusConflict, "That handle is already taken")
+		return
+	}
+	if isUniqueViolation(err, "users_email_key") {
+		respondWithError(w, http.StatusConflict, "That email is already in use")
+		return
+	}
 	if err != nil {
 		log.Printf("Could not create new user: %s", err)
 		respondWithError(w, 500, "Error trying to create new user")
 		return
 	}
-	userResp := User{
-		ID:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
+
+	// The account works straight away; verifying the email only matters
+	// where verifiedEmailRequired is set.
+	err = apiCfg.sendVerificationEmail(ctx, user.ID, user.Email)
+	if err != nil && !errors.Is(err, errNoMailer) {
+		log.Printf("Error sending verification email to user %s: %s", user.ID, err)
 	}
-	respondWithJSON(w, 201, userResp)
+
+	respondWithJSON(w, 201, newUser(user))
 
 }
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/mailer"
+)
+
+// emailVerificationTTL is how long an email verification link stays good
+// for.
+const emailVerificationTTL = 48 * time.Hour
+
+var errNoMailer = errors.New("no mailer is configured")
+
+// sendVerificationEmail emails a token to email that proves userID can read
+// mail sent there when it comes back to /api/users/verify.
+func (apiCfg *apiConfig) sendVerificationEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	if apiCfg.mailer == nil {
+		return errNoMailer
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = apiCfg.db.CreateEmailVerification(ctx, database.CreateEmailVerificationParams{
+		TokenHash: auth.HashRefreshToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: apiCfg.now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	how := fmt.Sprintf("send this token to POST /api/users/verify:\n\n%s", token)
+	if apiCfg.emailVerificationURL != "" {
+		how = fmt.Sprintf("follow this link:\n\n%s?token=%s", apiCfg.emailVerificationURL, url.QueryEscape(token))
+	}
+	return apiCfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your email for Chirpy",
+		Body: fmt.Sprintf("To confirm that this is the email address for your Chirpy account, %s\n\n"+
+			"If you did not sign up or change your email, you can ignore this email.\n", how),
+	})
+}
+
+// handlerVerifyEmail confirms the address a {"token": ...} from a
+// verification email was sent to. If it is the user's pending_email, it
+// becomes their email and they log in with it from then on.
+func (apiCfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	v, err := apiCfg.db.UseEmailVerification(ctx, database.UseEmailVerificationParams{
+		TokenHash: auth.HashRefreshToken(req.Token),
+		Now:       apiCfg.now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithProblem(w, http.StatusUnauthorized, "The verification token is not valid or has expired")
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking email verification: %s", err)
+		respondWithError(w, 500, "Error verifying email")
+		return
+	}
+
+	user, err := apiCfg.db.GetUserById(ctx, v.UserID)
+	if err != nil {
+		log.Printf("Error looking up user %s: %s", v.UserID, err)
+		respondWithError(w, 500, "Error verifying email")
+		return
+	}
+	// A token for an address the user has since moved away from proves
+	// nothing they still want.
+	if v.Email != user.Email && v.Email != user.PendingEmail.String {
+		respondWithProblem(w, http.StatusUnauthorized, "The verification token is not valid or has expired")
+		return
+	}
+
+	user, err = apiCfg.db.VerifyUserEmail(ctx, database.VerifyUserEmailParams{
+		ID:    user.ID,
+		Email: v.Email,
+	})
+	if isUniqueViolation(err, "users_email_key") {
+		respondWithError(w, http.StatusConflict, "That email is already in use")
+		return
+	}
+	if err != nil {
+		log.Printf("Error verifying email for user %s: %s", v.UserID, err)
+		respondWithError(w, 500, "Error verifying email")
+		return
+	}
+
+	respondWithJSON(w, 200, newUser(user))
+}
+
+// handlerResendVerification sends another verification email to the
+// requester's pending_email, or to their email if it is not verified yet.
+func (apiCfg *apiConfig) handlerResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := requestPrincipal(r).UserID
+
+	user, err := apiCfg.db.GetUserById(ctx, userID)
+	if err != nil {
+		log.Printf("Error looking up user %s: %s", userID, err)
+		respondWithError(w, 500, "Error sending verification email")
+		return
+	}
+
+	email := user.PendingEmail.String
+	if email == "" {
+		if user.VerifiedAt.Valid {
+			respondWithError(w, http.StatusConflict, "Your email is already verified")
+			return
+		}
+		email = user.Email
+	}
+
+	err = apiCfg.sendVerificationEmail(ctx, userID, email)
+	if errors.Is(err, errNoMailer) {
+		respondWithError(w, http.StatusServiceUnavailable, "Email verification is not available")
+		return
+	}
+	if err != nil {
+		log.Printf("Error sending verification email to user %s: %s", userID, err)
+		respondWithError(w, 500, "Error sending verification email")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// requireVerifiedEmail lets a request through to next only if the
+// requester's email is verified, when the server is set to require that.
+// It goes inside requireScope.
+func (apiCfg *apiConfig) requireVerifiedEmail(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !apiCfg.verifiedEmailRequired {
+			next(w, r)
+			return
+		}
+
+		userID := requestPrincipal(r).UserID
+		user, err := apiCfg.db.GetUserById(r.Context(), userID)
+		if err != nil {
+			log.Printf("Error looking up user %s: %s", userID, err)
+			respondWithError(w, 500, "Error checking email verification")
+			return
+		}
+		if !user.VerifiedAt.Valid {
+			respondWithProblem(w, http.StatusForbidden, "Verify your email address before chirping")
+			return
+		}
+		next(w, r)
+	}
+}
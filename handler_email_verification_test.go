@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEmailVerification(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	apiCfg.verifiedEmailRequired = true
+	mail := apiCfg.mailer.(*testMailer)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	bearer := "Bearer " + walt.Token
+	if walt.EmailVerified {
+		t.Fatalf("POST /api/login for a new user has email_verified = true")
+	}
+	if to := mail.sent[len(mail.sent)-1].To; to != "walt@example.com" {
+		t.Fatalf("signup verification email sent to %q, want walt@example.com", to)
+	}
+	signupToken := mail.lastToken(t)
+	createAndLogin(t, srv, "jesse@example.com", "04234")
+
+	chirp := map[string]string{"body": "Say my name"}
+	if resp := doJSON(t, srv, "POST", "/api/chirps", bearer, chirp, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("POST /api/chirps before verifying = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	verify := func(token string) (int, User) {
+		t.Helper()
+		var user User
+		resp := doJSON(t, srv, "POST", "/api/users/verify", "", map[string]string{"token": token}, &user)
+		return resp.StatusCode, user
+	}
+
+	// Moving to an address that is already taken is refused outright, and
+	// one that is free waits until it is verified.
+	if resp := doJSON(t, srv, "PUT", "/api/users", bearer, map[string]string{"email": "jesse@example.com", "password": "04234"}, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("PUT /api/users to a taken email = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+	if resp := doJSON(t, srv, "PUT", "/api/users", bearer, map[string]string{"email": "heisenberg", "password": "04234"}, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("PUT /api/users to a malformed email = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	var updated User
+	doJSON(t, srv, "PUT", "/api/users", bearer, map[string]string{"email": "heisenberg@example.com", "password": "04234"}, &updated)
+	if updated.Email != "walt@example.com" || updated.PendingEmail != "heisenberg@example.com" {
+		t.Fatalf("PUT /api/users with a new email = %+v, want it pending", updated)
+	}
+	staleToken := mail.lastToken(t)
+	doJSON(t, srv, "PUT", "/api/users", bearer, map[string]string{"email": "ww@example.com", "password": "04234"}, nil)
+	pendingToken := mail.lastToken(t)
+	if to := mail.sent[len(mail.sent)-1].To; to != "ww@example.com" {
+		t.Fatalf("change verification email sent to %q, want ww@example.com", to)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		wantCode    int
+		wantEmail   string
+		wantPending string
+	}{
+		{name: "Unknown token", token: "nope", wantCode: http.StatusUnauthorized},
+		{name: "Signup token", token: signupToken, wantCode: http.StatusOK, wantEmail: "walt@example.com", wantPending: "ww@example.com"},
+		{name: "Used token", token: signupToken, wantCode: http.StatusUnauthorized},
+		{name: "Token for a replaced pending email", token: staleToken, wantCode: http.StatusUnauthorized},
+		{name: "Pending email token", token: pendingToken, wantCode: http.StatusOK, wantEmail: "ww@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, user := verify(tt.token)
+			if code != tt.wantCode {
+				t.Fatalf("POST /api/users/verify status = %d, want %d", code, tt.wantCode)
+			}
+			if code == http.StatusOK && (user.Email != tt.wantEmail || !user.EmailVerified || user.PendingEmail != tt.wantPending) {
+				t.Errorf("POST /api/users/verify = %+v, want %s verified and %q pending", user, tt.wantEmail, tt.wantPending)
+			}
+		})
+	}
+
+	for email, wantCode := range map[string]int{"walt@example.com": http.StatusUnauthorized, "ww@example.com": http.StatusOK} {
+		if resp := doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": email, "password": "04234"}, nil); resp.StatusCode != wantCode {
+			t.Errorf("POST /api/login as %s = %d, want %d", email, resp.StatusCode, wantCode)
+		}
+	}
+	if resp := doJSON(t, srv, "POST", "/api/chirps", bearer, chirp, nil); resp.StatusCode != http.StatusCreated {
+		t.Errorf("POST /api/chirps after verifying = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	if resp := doJSON(t, srv, "POST", "/api/users/verify/resend", bearer, nil, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("POST /api/users/verify/resend when verified = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type FollowResponse struct {
+	UserSummary
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// otherUserTarget returns the requester and parses the {userID} path
+// value, which must be someone else, for endpoints where one user acts on
+// another. It writes the error response itself and returns ok=false when the
+// request cannot go on.
+func (apiCfg *apiConfig) otherUserTarget(w http.ResponseWriter, r *http.Request) (requesterID, targetID uuid.UUID, ok bool) {
+	requesterID = requestPrincipal(r).UserID
+
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "userID is not a valid id")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if requesterID == targetID {
+		respondWithError(w, 400, "You cannot do that to yourself")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return requesterID, targetID, true
+}
+
+func (apiCfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	followerID, followeeID, ok := apiCfg.otherUserTarget(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := apiCfg.db.GetUserById(ctx, followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "User could not be found.")
+			return
+		}
+		log.Printf("Error looking up user to follow: %s", err)
+		respondWithError(w, 500, "Error following user")
+		return
+	}
+
+	blocked, err := apiCfg.db.IsBlocked(ctx, database.IsBlockedParams{
+		BlockerID: followeeID,
+		BlockedID: followerID,
+	})
+	if err != nil {
+		log.Printf("Error checking blocks: %s", err)
+		respondWithError(w, 500, "Error following user")
+		return
+	}
+	if blocked {
+		respondWithError(w, 403, "You cannot follow this user")
+		return
+	}
+
+	followed, err := apiCfg.db.FollowUser(ctx, database.FollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("Error following user: %s", err)
+		respondWithError(w, 500, "Error following user")
+		return
+	}
+	if followed > 0 {
+		apiCfg.notify(ctx, followeeID, followerID, notificationKindFollow, uuid.NullUUID{})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (apiCfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := apiCfg.otherUserTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := apiCfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("Error unfollowing user: %s", err)
+		respondWithError(w, 500, "Error unfollowing user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (apiCfg *apiConfig) handlerListFollowers(w http.ResponseWriter, r *http.Request) {
+	apiCfg.listFollows(w, r, func(userID uuid.UUID, page pageParams) ([]database.ListFollowersRow, error) {
+		cursorCreatedAt, cursorID := page.cursorArgs()
+		return apiCfg.db.ListFollowers(r.Context(), database.ListFollowersParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			Limit:           int32(page.Limit + 1),
+		})
+	})
+}
+
+func (apiCfg *apiConfig) handlerListFollowing(w http.ResponseWriter, r *http.Request) {
+	apiCfg.listFollows(w, r, func(userID uuid.UUID, page pageParams) ([]database.ListFollowersRow, error) {
+		cursorCreatedAt, cursorID := page.cursorArgs()
+		rows, err := apiCfg.db.ListFollowing(r.Context(), database.ListFollowingParams{
+			UserID:          userID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			Limit:           int32(page.Limit + 1),
+		})
+		following := make([]database.ListFollowersRow, 0, len(rows))
+		for _, row := range rows {
+			following = append(following, database.ListFollowersRow(row))
+		}
+		return following, err
+	})
+}
+
+// listFollows serves a newest-first page of the followers or followees of
+// the {userID} path value, as returned by list.
+func (apiCfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, list func(uuid.UUID, pageParams) ([]database.ListFollowersRow, error)) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "userID is not a valid id")
+		return
+	}
+
+	page, err := parseFeedPageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := list(userID, page)
+	if err != nil {
+		log.Printf("Error listing follows: %s", err)
+		respondWithError(w, 500, "Error listing follows")
+		return
+	}
+
+	rows = finishPage(w, r, page, rows, func(row database.ListFollowersRow) pageCursor {
+		return pageCursor{CreatedAt: row.FollowedAt, ID: row.User.ID}
+	})
+
+	resp := []FollowResponse{}
+	for _, row := range rows {
+		resp = append(resp, FollowResponse{
+			UserSummary: UserSummary{
+				ID:          row.User.ID,
+				Handle:      row.User.Handle,
+				CreatedAt:   row.User.CreatedAt,
+				IsChirpyRed: row.User.IsChirpyRed,
+			},
+			FollowedAt: row.FollowedAt,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
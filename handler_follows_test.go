@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFollowAndTimeline(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+	saul := createAndLogin(t, srv, "saul@example.com", "54321")
+
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "walt"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]string{"body": "jesse"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+saul.Token, map[string]string{"body": "saul"}, nil)
+
+	followPath := "/api/users/" + jesse.ID.String() + "/follow"
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		wantCode int
+	}{
+		{name: "No token", method: "POST", path: followPath, token: "", wantCode: 401},
+		{name: "Follow self", method: "POST", path: "/api/users/" + walt.ID.String() + "/follow", token: walt.Token, wantCode: 400},
+		{name: "Unknown user", method: "POST", path: "/api/users/" + uuid.NewString() + "/follow", token: walt.Token, wantCode: 404},
+		{name: "Follow", method: "POST", path: followPath, token: walt.Token, wantCode: 204},
+		{name: "Follow again", method: "POST", path: followPath, token: walt.Token, wantCode: 204},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := ""
+			if tt.token != "" {
+				auth = "Bearer " + tt.token
+			}
+			if resp := doJSON(t, srv, tt.method, tt.path, auth, nil, nil); resp.StatusCode != tt.wantCode {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	var timeline []ChirpResponse
+	doJSON(t, srv, "GET", "/api/timeline", "Bearer "+walt.Token, nil, &timeline)
+	if got := chirpBodies(timeline); got != "jesse,walt" {
+		t.Errorf("timeline = %q, want %q", got, "jesse,walt")
+	}
+
+	var followers []FollowResponse
+	doJSON(t, srv, "GET", "/api/users/"+jesse.ID.String()+"/followers", "", nil, &followers)
+	if len(followers) != 1 || followers[0].ID != walt.ID {
+		t.Errorf("followers = %+v, want just %v", followers, walt.ID)
+	}
+	var following []FollowResponse
+	doJSON(t, srv, "GET", "/api/users/"+walt.ID.String()+"/following", "", nil, &following)
+	if len(following) != 1 || following[0].ID != jesse.ID {
+		t.Errorf("following = %+v, want just %v", following, jesse.ID)
+	}
+
+	if resp := doJSON(t, srv, "DELETE", followPath, "Bearer "+walt.Token, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE %s status = %d, want %d", followPath, resp.StatusCode, http.StatusNoContent)
+	}
+	doJSON(t, srv, "GET", "/api/timeline", "Bearer "+walt.Token, nil, &timeline)
+	if got := chirpBodies(timeline); got != "walt" {
+		t.Errorf("timeline after unfollow = %q, want %q", got, "walt")
+	}
+}
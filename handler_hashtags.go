@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// handlerHashtagChirps lists the chirps tagged with a hashtag, newest first.
+// The tag may be given with or without its #.
+func (apiCfg *apiConfig) handlerHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if !validHashtag(tag) {
+		respondWithError(w, http.StatusBadRequest, "Not a valid hashtag")
+		return
+	}
+
+	page, err := parseFeedPageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	chirps, err := apiCfg.db.ListChirpsByHashtag(ctx, database.ListChirpsByHashtagParams{
+		Tag:             tag,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           int32(page.Limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error listing chirps for #%s: %s", tag, err)
+		respondWithError(w, 500, "Error getting chirps")
+		return
+	}
+
+	chirps = finishPage(w, r, page, chirps, chirpCursor)
+
+	response_chirps, err := apiCfg.chirpResponses(ctx, chirps, apiCfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error rendering chirps for #%s: %s", tag, err)
+		respondWithError(w, 500, "Error getting chirps")
+		return
+	}
+	respondWithJSON(w, 200, response_chirps)
+}
+
+// handlerTrendingHashtags returns the hashtags trending over the last day,
+// highest score first. It is served from a cache rather than computed per
+// request.
+func (apiCfg *apiConfig) handlerTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	limit, err := parsePageLimit(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tags, err := apiCfg.trending.get(r.Context())
+	if err != nil {
+		log.Printf("Error getting trending hashtags: %s", err)
+		respondWithError(w, 500, "Error getting trending hashtags")
+		return
+	}
+	respondWithJSON(w, 200, tags[:min(limit, len(tags))])
+}
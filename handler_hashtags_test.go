@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/memstore"
+)
+
+func trendingTags(tags []TrendingHashtag) string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Tag)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestHashtags(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	clock := time.Now().UTC()
+	apiCfg.db.(*memstore.Store).Now = func() time.Time { return clock }
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	chirpAt := func(age time.Duration, body string) {
+		t.Helper()
+		clock = time.Now().UTC().Add(-age)
+		doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": body}, nil)
+	}
+	chirpAt(30*time.Hour, "#ancient history")
+	chirpAt(12*time.Hour, "#old news #Chemistry")
+	chirpAt(12*time.Hour, "more #old news")
+	chirpAt(0, "#new #chemistry")
+	clock = time.Now().UTC()
+
+	var chirps []ChirpResponse
+	if resp := doJSON(t, srv, "GET", "/api/hashtags/chemistry/chirps", "", nil, &chirps); resp.StatusCode != 200 {
+		t.Fatalf("GET hashtag chirps status = %d, want 200", resp.StatusCode)
+	}
+	if got := chirpBodies(chirps); got != "#new #chemistry,#old news #Chemistry" {
+		t.Errorf("#chemistry chirps = %q", got)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/hashtags/%23Chemistry/chirps?limit=1", "", nil, &chirps); pageLinks(resp)["next"] == "" || len(chirps) != 1 {
+		t.Errorf("#Chemistry page 1 = %d chirps, want 1 and a next link", len(chirps))
+	}
+	if resp := doJSON(t, srv, "GET", "/api/hashtags/no-such!/chirps", "", nil, nil); resp.StatusCode != 400 {
+		t.Errorf("invalid tag status = %d, want 400", resp.StatusCode)
+	}
+
+	// #new was used once just now, #old twice two half lives ago, and
+	// #ancient has fallen out of the window.
+	var trending []TrendingHashtag
+	doJSON(t, srv, "GET", "/api/hashtags/trending", "", nil, &trending)
+	if got := trendingTags(trending); got != "chemistry,new,old" {
+		t.Errorf("trending = %q, want %q", got, "chemistry,new,old")
+	}
+	doJSON(t, srv, "GET", "/api/hashtags/trending?limit=1", "", nil, &trending)
+	if got := trendingTags(trending); got != "chemistry" {
+		t.Errorf("trending with limit=1 = %q, want %q", got, "chemistry")
+	}
+
+	// Trending tags come from the cache until it is refreshed.
+	chirpAt(0, "#fresh #fresh_too")
+	chirpAt(0, "#fresh")
+	doJSON(t, srv, "GET", "/api/hashtags/trending", "", nil, &trending)
+	if got := trendingTags(trending); got != "chemistry,new,old" {
+		t.Errorf("trending before refresh = %q, want cached %q", got, "chemistry,new,old")
+	}
+	if err := apiCfg.trending.refresh(context.Background()); err != nil {
+		t.Fatalf("refreshing trending hashtags: %v", err)
+	}
+	doJSON(t, srv, "GET", "/api/hashtags/trending", "", nil, &trending)
+	if got := trendingTags(trending); got != "fresh,chemistry,fresh_too,new,old" {
+		t.Errorf("trending after refresh = %q", got)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+type SigningKeyResponse struct {
+	ID        string     `json:"kid"`
+	Algorithm string     `json:"algorithm"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiresAt *time.Time `json:"retires_at"`
+	// Current is set on the key new tokens are signed with.
+	Current bool `json:"current"`
+}
+
+func newSigningKeyResponse(key auth.SigningKey) SigningKeyResponse {
+	resp := SigningKeyResponse{
+		ID:        key.ID,
+		Algorithm: key.Algorithm,
+		CreatedAt: key.CreatedAt,
+	}
+	if !key.RetiresAt.IsZero() {
+		resp.RetiresAt = &key.RetiresAt
+	}
+	return resp
+}
+
+// handlerJWKS publishes the public keys access tokens are signed with, so
+// that other services can verify them.
+func (apiCfg *apiConfig) handlerJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(jwksMaxAge.Seconds())))
+	respondWithJSON(w, 200, apiCfg.keys.JWKS())
+}
+
+// handlerListSigningKeys lists the signing keys that have not retired,
+// oldest first.
+func (apiCfg *apiConfig) handlerListSigningKeys(w http.ResponseWriter, r *http.Request) {
+	current, _ := apiCfg.keys.CurrentKey()
+	keys := apiCfg.keys.Keys()
+	resps := make([]SigningKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp := newSigningKeyResponse(key)
+		resp.Current = key.ID == current.ID
+		resps = append(resps, resp)
+	}
+	respondWithJSON(w, 200, resps)
+}
+
+// handlerRotateSigningKey makes a new signing key, optionally for the
+// algorithm in {"algorithm": "..."}. The keys it replaces are accepted
+// until their retires_at.
+func (apiCfg *apiConfig) handlerRotateSigningKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Algorithm string `json:"algorithm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	key, err := apiCfg.keys.Rotate(r.Context(), req.Algorithm)
+	if errors.Is(err, auth.ErrUnsupportedAlgorithm) {
+		respondWithError(w, http.StatusBadRequest, "algorithm must be EdDSA or RS256")
+		return
+	}
+	if err != nil {
+		log.Printf("Error rotating signing key: %s", err)
+		respondWithError(w, 500, "Error rotating signing key")
+		return
+	}
+
+	log.Printf("Rotated signing key, now signing with %s (%s)", key.ID, key.Algorithm)
+	resp := newSigningKeyResponse(key)
+	resp.Current = true
+	respondWithJSON(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/memstore"
+)
+
+func tokenKeyID(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified() error = %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	_, srv := newTestServer(t)
+	login := createAndLogin(t, srv, "walt@example.com", "04234")
+	oldKid := tokenKeyID(t, login.Token)
+
+	var jwks struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Alg     string `json:"alg"`
+		} `json:"keys"`
+	}
+	resp := doJSON(t, srv, "GET", "/.well-known/jwks.json", "", nil, &jwks)
+	if resp.StatusCode != http.StatusOK || len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != oldKid || jwks.Keys[0].Alg != "EdDSA" {
+		t.Fatalf("GET /.well-known/jwks.json status = %d, keys = %+v, want the EdDSA key %s", resp.StatusCode, jwks.Keys, oldKid)
+	}
+	if resp.Header.Get("Cache-Control") == "" {
+		t.Errorf("GET /.well-known/jwks.json has no Cache-Control header")
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          interface{}
+		wantCode      int
+	}{
+		{name: "No admin key", body: nil, wantCode: http.StatusUnauthorized},
+		{name: "User token", authorization: "Bearer " + login.Token, body: nil, wantCode: http.StatusForbidden},
+		{name: "Unsupported algorithm", authorization: "ApiKey test-admin-key", body: map[string]string{"algorithm": "HS256"}, wantCode: http.StatusBadRequest},
+		{name: "RS256", authorization: "ApiKey test-admin-key", body: map[string]string{"algorithm": "RS256"}, wantCode: http.StatusCreated},
+	}
+	var rotated SigningKeyResponse
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doJSON(t, srv, "POST", "/admin/keys/rotate", tt.authorization, tt.body, &rotated); resp.StatusCode != tt.wantCode {
+				t.Errorf("POST /admin/keys/rotate status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+	if rotated.Algorithm != "RS256" || !rotated.Current || rotated.RetiresAt != nil {
+		t.Fatalf("POST /admin/keys/rotate = %+v, want the current RS256 key", rotated)
+	}
+
+	var keys []SigningKeyResponse
+	doJSON(t, srv, "GET", "/admin/keys", "ApiKey test-admin-key", nil, &keys)
+	if len(keys) != 2 || keys[0].ID != oldKid || keys[0].Current || keys[0].RetiresAt == nil || keys[1].ID != rotated.ID || !keys[1].Current {
+		t.Errorf("GET /admin/keys = %+v, want the retiring old key then the current new one", keys)
+	}
+	doJSON(t, srv, "GET", "/.well-known/jwks.json", "", nil, &jwks)
+	if len(jwks.Keys) != 2 || jwks.Keys[1].KeyType != "RSA" {
+		t.Errorf("GET /.well-known/jwks.json after rotation keys = %+v, want the old key and an RSA key", jwks.Keys)
+	}
+
+	// Tokens signed before the rotation keep working through the overlap.
+	if resp := doJSON(t, srv, "GET", "/api/sessions", "Bearer "+login.Token, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /api/sessions with a token from the old key status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	code, refreshed := refresh(t, srv, login.RefreshToken)
+	if code != http.StatusOK || tokenKeyID(t, refreshed.Token) != rotated.ID {
+		t.Errorf("POST /api/refresh status = %d, kid = %s, want a token signed by %s", code, tokenKeyID(t, refreshed.Token), rotated.ID)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/sessions", "Bearer "+refreshed.Token, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /api/sessions with a token from the new key status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestKeyStoreEncryptsKeys(t *testing.T) {
+	ctx := context.Background()
+	db := memstore.New()
+	cipher, err := auth.NewKeyCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyCipher() error = %v", err)
+	}
+	store := dbKeyStore{db: db, cipher: cipher}
+
+	key, _ := auth.GenerateSigningKey(auth.AlgEdDSA)
+	der, _ := auth.MarshalSigningKey(key.Key)
+	if err := store.Add(ctx, key, time.Now()); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	// A key stored before keys were encrypted.
+	old, _ := auth.GenerateSigningKey(auth.AlgEdDSA)
+	oldDER, _ := auth.MarshalSigningKey(old.Key)
+	if _, err := db.CreateSigningKey(ctx, database.CreateSigningKeyParams{ID: old.ID, Algorithm: old.Algorithm, PrivateKey: oldDER}); err != nil {
+		t.Fatalf("CreateSigningKey() error = %v", err)
+	}
+
+	loaded, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("Load() returned %d keys, want 2", len(loaded))
+	}
+	for _, k := range loaded {
+		want := map[string]auth.SigningKey{key.ID: key, old.ID: old}[k.ID]
+		if !k.Key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(want.Key.Public()) {
+			t.Errorf("Load() key %s differs from the one stored", k.ID)
+		}
+	}
+
+	rows, _ := db.ListSigningKeys(ctx)
+	for _, row := range rows {
+		if bytes.Contains(row.PrivateKey, der) || bytes.Contains(row.PrivateKey, oldDER) {
+			t.Errorf("signing key %s is stored unencrypted", row.ID)
+		}
+	}
+
+	if _, err := (dbKeyStore{db: db}).Load(ctx); err == nil {
+		t.Errorf("Load() without the cipher error = nil, want an error")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/mrbaker1917/chirpy/internal/lockout"
+)
+
+// handlerUnlockLogins forgets the failed logins counted against
+// {"email": ...}, {"ip": ...} or both, so they can log in again straight
+// away.
+func (apiCfg *apiConfig) handlerUnlockLogins(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+		IP    string `json:"ip"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	var keys []string
+	if req.Email != "" {
+		keys = append(keys, lockout.AccountKey(req.Email))
+	}
+	if req.IP != "" {
+		keys = append(keys, lockout.IPKey(req.IP))
+	}
+	if len(keys) == 0 {
+		respondWithError(w, http.StatusBadRequest, "email or ip is required")
+		return
+	}
+
+	for _, key := range keys {
+		if err := apiCfg.logins.Unlock(r.Context(), key); err != nil {
+			log.Printf("Error unlocking %s: %s", key, err)
+			respondWithError(w, 500, "Error unlocking logins")
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/lockout"
+)
+
+func TestLoginLockout(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	apiCfg.now = func() time.Time { return clock }
+	createAndLogin(t, srv, "walt@example.com", "04234")
+	createAndLogin(t, srv, "jesse@example.com", "04234")
+
+	login := func(email, password string) *http.Response {
+		t.Helper()
+		return doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": email, "password": password}, nil)
+	}
+
+	for i := int32(0); i < lockout.DefaultAccountPolicy.FreeAttempts; i++ {
+		if resp := login("walt@example.com", "wrongpass"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("POST /api/login with a wrong password = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+	resp := login("walt@example.com", "04234")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1" {
+		t.Fatalf("POST /api/login after %d failures = %d with Retry-After %q, want %d and 1", lockout.DefaultAccountPolicy.FreeAttempts, resp.StatusCode, resp.Header.Get("Retry-After"), http.StatusTooManyRequests)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("POST /api/login while waiting Content-Type = %q, want application/problem+json", ct)
+	}
+	if resp := login("jesse@example.com", "04234"); resp.StatusCode != http.StatusOK {
+		t.Errorf("POST /api/login for another account = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// Attempts turned away while waiting are not counted, but each failure
+	// after the wait doubles the next one.
+	clock = clock.Add(time.Second)
+	login("walt@example.com", "wrongpass")
+	if resp := login("walt@example.com", "04234"); resp.Header.Get("Retry-After") != "2" {
+		t.Errorf("POST /api/login after another failure Retry-After = %q, want 2", resp.Header.Get("Retry-After"))
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          map[string]string
+		wantCode      int
+	}{
+		{name: "Not an admin", authorization: "", body: map[string]string{"email": "walt@example.com"}, wantCode: http.StatusUnauthorized},
+		{name: "Nothing to unlock", authorization: adminAuth, body: map[string]string{}, wantCode: http.StatusBadRequest},
+		{name: "Unlock account", authorization: adminAuth, body: map[string]string{"email": "Walt@example.com"}, wantCode: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doJSON(t, srv, "POST", "/admin/lockouts/unlock", tt.authorization, tt.body, nil); resp.StatusCode != tt.wantCode {
+				t.Errorf("POST /admin/lockouts/unlock = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+	if resp := login("walt@example.com", "04234"); resp.StatusCode != http.StatusOK {
+		t.Errorf("POST /api/login after unlocking = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// Guessing across many accounts from one address locks the address.
+	for i := int32(0); i < lockout.DefaultIPPolicy.FreeAttempts; i++ {
+		login(fmt.Sprintf("user%d@example.com", i), "wrongpass")
+	}
+	if resp := login("jesse@example.com", "04234"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("POST /api/login from a guessing IP = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	doJSON(t, srv, "POST", "/admin/lockouts/unlock", adminAuth, map[string]string{"ip": "127.0.0.1"}, nil)
+	if resp := login("jesse@example.com", "04234"); resp.StatusCode != http.StatusOK {
+		t.Errorf("POST /api/login after unlocking the IP = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/mrbaker1917/chirpy/internal/auth"
@@ -27,48 +29,108 @@ func (apiCfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	wait, err := apiCfg.logins.Check(ctx, reqBdy.Email, clientIP(r))
+	if err != nil {
+		log.Printf("Error checking login failures: %s", err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+	if wait > 0 {
+		respondTooManyLogins(w, wait)
+		return
+	}
+
 	if len(reqBdy.Email) < 5 || len(reqBdy.Password) < 5 {
-		respondWithError(w, 401, "Incorrect email or password")
+		apiCfg.rejectLogin(w, r, reqBdy.Email, "Incorrect email or password")
 		return
 	}
 
 	user, err := apiCfg.db.GetUserByEmail(ctx, reqBdy.Email)
 	if err != nil {
-		respondWithError(w, 401, "Incorrect email or password")
+		apiCfg.rejectLogin(w, r, reqBdy.Email, "Incorrect email or password")
 		return
 	}
 
-	valid, err := auth.CheckPasswordHash(reqBdy.Password, user.HashedPassword)
+	valid, rehash, err := apiCfg.passwordParams.Check(reqBdy.Password, user.HashedPassword)
 	if err != nil {
-		respondWithError(w, 401, "Incorrect email or password")
+		apiCfg.rejectLogin(w, r, reqBdy.Email, "Incorrect email or password")
 		return
 	}
 
 	if !valid {
-		respondWithError(w, 401, "Incorrect email or password")
+		apiCfg.rejectLogin(w, r, reqBdy.Email, "Incorrect email or password")
 		return
 	}
+	if rehash {
+		apiCfg.rehashPassword(ctx, user, reqBdy.Password)
+	}
 
-	token, err := auth.MakeJWT(user.ID, apiCfg.secret)
-	if err != nil {
-		log.Printf("Error acquiring JWT: %s", err)
-		respondWithError(w, 500, "Error acquiring JWT")
+	totp, err := apiCfg.db.GetUserTotp(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting TOTP for user %s: %s", user.ID, err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+	if err == nil && totp.ConfirmedAt.Valid {
+		apiCfg.startMFAChallenge(w, r, user.ID)
 		return
 	}
 
-	r_token, err := auth.MakeRefreshToken()
+	apiCfg.completeLogin(w, r, user)
+}
+
+// rehashPassword replaces user's password hash with one made with the
+// current parameters, so that raising them takes effect as users log in
+// rather than needing everyone to reset their password. A failure only
+// leaves the old hash in place, so it is logged and the login goes on.
+func (apiCfg *apiConfig) rehashPassword(ctx context.Context, user database.User, password string) {
+	hashed, err := apiCfg.passwordParams.Hash(password)
 	if err != nil {
-		log.Printf("Error creating Refresh Token: %s", err)
-		respondWithError(w, 500, "Error creating Refresh Token")
+		log.Printf("Error rehashing password for user %s: %s", user.ID, err)
 		return
 	}
+	err = apiCfg.db.RehashUserPassword(ctx, database.RehashUserPasswordParams{
+		NewHash: hashed,
+		ID:      user.ID,
+		OldHash: user.HashedPassword,
+	})
+	if err != nil {
+		log.Printf("Error rehashing password for user %s: %s", user.ID, err)
+	}
+}
+
+// rejectLogin counts a failed login for email from the request's IP and
+// responds with a 401.
+func (apiCfg *apiConfig) rejectLogin(w http.ResponseWriter, r *http.Request, email, msg string) {
+	if _, err := apiCfg.logins.Failed(r.Context(), email, clientIP(r)); err != nil {
+		log.Printf("Error counting login failure: %s", err)
+	}
+	respondWithProblem(w, http.StatusUnauthorized, msg)
+}
+
+// completeLogin starts a new session for user, whose credentials have all
+// been checked, and responds with its access and refresh tokens.
+func (apiCfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	// Only now, with the second factor checked too, are the account's
+	// failures forgotten.
+	if err := apiCfg.logins.Succeeded(r.Context(), user.Email); err != nil {
+		log.Printf("Error clearing login failures: %s", err)
+	}
 
-	_, err = apiCfg.db.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
-		Token:     r_token,
+	sessionID := uuid.New()
+	token, err := apiCfg.keys.MakeAccessToken(auth.AccessToken{
 		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(60 * 24 * time.Hour),
-	})
+		SessionID: sessionID,
+		Version:   user.TokenVersion,
+		Scopes:    auth.UserScopes,
+	}, accessTokenTTL)
+	if err != nil {
+		log.Printf("Error acquiring JWT: %s", err)
+		respondWithError(w, 500, "Error acquiring JWT")
+		return
+	}
 
+	r_token, err := apiCfg.issueRefreshToken(r, user.ID, sessionID, sql.NullString{})
 	if err != nil {
 		log.Printf("Error saving refresh token: %s", err)
 		respondWithError(w, 500, "Error saving refresh token")
@@ -76,22 +138,14 @@ func (apiCfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type userWithToken struct {
-		ID           uuid.UUID `json:"id"`
-		CreatedAt    time.Time `json:"created_at"`
-		UpdatedAt    time.Time `json:"updated_at"`
-		Email        string    `json:"email"`
-		Token        string    `json:"token"`
-		RefreshToken string    `json:"refresh_token"`
-		IsChirpyRed  bool      `json:"is_chirpy_red"`
+		User
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	respondWithJSON(w, 200, userWithToken{
-		ID:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
+		User:         newUser(user),
 		Token:        token,
 		RefreshToken: r_token,
-		IsChirpyRed:  user.IsChirpyRed,
 	})
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestLoginRehashesPassword(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	createAndLogin(t, srv, "walt@example.com", "04234")
+	old, err := apiCfg.db.GetUserByEmail(context.Background(), "walt@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail() error = %v", err)
+	}
+
+	// Raising the cost leaves existing hashes alone until their users log
+	// in with the right password.
+	apiCfg.passwordParams.Iterations++
+	creds := map[string]string{"email": "walt@example.com"}
+	for _, password := range []string{"wrongpass", "04234"} {
+		creds["password"] = password
+		doJSON(t, srv, "POST", "/api/login", "", creds, nil)
+		user, err := apiCfg.db.GetUserByEmail(context.Background(), "walt@example.com")
+		if err != nil {
+			t.Fatalf("GetUserByEmail() error = %v", err)
+		}
+		changed := user.HashedPassword != old.HashedPassword
+		if want := password == "04234"; changed != want {
+			t.Fatalf("hash changed after logging in with %q = %v, want %v", password, changed, want)
+		}
+		if match, rehash, _ := apiCfg.passwordParams.Check("04234", user.HashedPassword); !match || rehash != !changed {
+			t.Errorf("Check() after logging in with %q = %v, %v", password, match, rehash)
+		}
+	}
+	if resp := doJSON(t, srv, "POST", "/api/login", "", creds, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("POST /api/login after rehashing = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
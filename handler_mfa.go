@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+const (
+	// mfaTokenTTL is how long a user has to enter their code after their
+	// password has been accepted.
+	mfaTokenTTL = 5 * time.Minute
+	// maxMFAAttempts is how many codes may be tried against one mfa_token.
+	maxMFAAttempts = 5
+
+	recoveryCodeCount = 10
+	totpIssuer        = "Chirpy"
+)
+
+// secondFactor is the code a request offers as proof of the second factor:
+// a code from the authenticator app or one of the recovery codes.
+type secondFactor struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// checkSecondFactor reports whether f is a valid code for userID, using it up
+// so that it cannot be accepted again.
+func (apiCfg *apiConfig) checkSecondFactor(ctx context.Context, userID uuid.UUID, f secondFactor) (bool, error) {
+	if f.RecoveryCode != "" {
+		n, err := apiCfg.db.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashRecoveryCode(f.RecoveryCode),
+		})
+		return n == 1, err
+	}
+
+	totp, err := apiCfg.db.GetUserTotp(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	step, ok := auth.ValidateTOTP(totp.Secret, f.Code, apiCfg.now())
+	if !ok {
+		return false, nil
+	}
+	// A code is only good once, even within its period.
+	n, err := apiCfg.db.AdvanceUserTotpStep(ctx, database.AdvanceUserTotpStepParams{
+		Step:   step,
+		UserID: userID,
+	})
+	return n == 1, err
+}
+
+// startMFAChallenge answers a login whose password was right for a user with
+// two-factor authentication, with an mfa_token to send to /api/login/mfa
+// along with their code.
+func (apiCfg *apiConfig) startMFAChallenge(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error making MFA token: %s", err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+
+	expiresAt := apiCfg.now().Add(mfaTokenTTL)
+	err = apiCfg.db.CreateMfaChallenge(r.Context(), database.CreateMfaChallengeParams{
+		TokenHash: auth.HashRefreshToken(token),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Printf("Error saving MFA challenge: %s", err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+
+	type mfaChallenge struct {
+		MFARequired bool      `json:"mfa_required"`
+		MFAToken    string    `json:"mfa_token"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+	respondWithJSON(w, 200, mfaChallenge{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// handlerLoginMFA finishes a login started with a password, trading an
+// mfa_token and a code or recovery code for access and refresh tokens.
+func (apiCfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+		secondFactor
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+	if req.MFAToken == "" || (req.Code == "") == (req.RecoveryCode == "") {
+		respondWithError(w, http.StatusBadRequest, "mfa_token and one of code or recovery_code are required")
+		return
+	}
+
+	tokenHash := auth.HashRefreshToken(req.MFAToken)
+	challenge, err := apiCfg.db.AttemptMfaChallenge(ctx, database.AttemptMfaChallengeParams{
+		TokenHash:   tokenHash,
+		Now:         apiCfg.now(),
+		MaxAttempts: maxMFAAttempts,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithProblem(w, http.StatusUnauthorized, "The MFA token is not valid or has expired; log in again")
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking MFA challenge: %s", err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+
+	user, err := apiCfg.db.GetUserById(ctx, challenge.UserID)
+	if err != nil {
+		log.Printf("Error getting user %s: %s", challenge.UserID, err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+	// Wrong codes count against the account like wrong passwords, so that
+	// someone with the password cannot guess codes a challenge at a time.
+	wait, err := apiCfg.logins.Check(ctx, user.Email, clientIP(r))
+	if err != nil {
+		log.Printf("Error checking login failures: %s", err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+	if wait > 0 {
+		respondTooManyLogins(w, wait)
+		return
+	}
+
+	ok, err := apiCfg.checkSecondFactor(ctx, challenge.UserID, req.secondFactor)
+	if err != nil {
+		log.Printf("Error checking second factor for user %s: %s", challenge.UserID, err)
+		respondWithError(w, 500, "Error logging in")
+		return
+	}
+	if !ok {
+		apiCfg.rejectLogin(w, r, user.Email, "Incorrect code")
+		return
+	}
+
+	if err := apiCfg.db.DeleteMfaChallenge(ctx, tokenHash); err != nil {
+		log.Printf("Error deleting MFA challenge: %s", err)
+	}
+	apiCfg.completeLogin(w, r, user)
+}
+
+// handlerEnrollTOTP starts setting up an authenticator app, responding with
+// a new secret and its otpauth:// URI. Two-factor authentication is not
+// turned on until a code from the app is sent to /api/mfa/totp/confirm.
+func (apiCfg *apiConfig) handlerEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+
+	user, err := apiCfg.db.GetUserById(r.Context(), tok.UserID)
+	if err != nil {
+		log.Printf("Error getting user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error enrolling authenticator")
+		return
+	}
+
+	secret := auth.GenerateTOTPSecret()
+	n, err := apiCfg.db.SetPendingUserTotp(r.Context(), database.SetPendingUserTotpParams{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		log.Printf("Error saving TOTP secret: %s", err)
+		respondWithError(w, 500, "Error enrolling authenticator")
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already on")
+		return
+	}
+
+	type enrollment struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+	respondWithJSON(w, http.StatusCreated, enrollment{
+		Secret:     secret,
+		OtpauthURI: auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+// handlerGetTOTP reports whether two-factor authentication is on and how
+// many recovery codes are left.
+func (apiCfg *apiConfig) handlerGetTOTP(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+
+	totp, err := apiCfg.db.GetUserTotp(r.Context(), tok.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting TOTP for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error getting two-factor authentication")
+		return
+	}
+	remaining, err := apiCfg.db.CountUnusedRecoveryCodes(r.Context(), tok.UserID)
+	if err != nil {
+		log.Printf("Error counting recovery codes for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error getting two-factor authentication")
+		return
+	}
+
+	type totpStatus struct {
+		Enabled                bool  `json:"enabled"`
+		RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+	}
+	respondWithJSON(w, 200, totpStatus{
+		Enabled:                totp.ConfirmedAt.Valid,
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// handlerConfirmTOTP turns on two-factor authentication once {"code": ...}
+// shows the authenticator app has the secret, and responds with the
+// recovery codes. They are only stored hashed, so this is the one time they
+// can be shown.
+func (apiCfg *apiConfig) handlerConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+	ctx := r.Context()
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	totp, err := apiCfg.db.GetUserTotp(ctx, tok.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "No authenticator is being enrolled")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting TOTP for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error confirming authenticator")
+		return
+	}
+	if totp.ConfirmedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already on")
+		return
+	}
+	step, ok := auth.ValidateTOTP(totp.Secret, req.Code, apiCfg.now())
+	if !ok {
+		respondWithProblem(w, http.StatusUnauthorized, "Incorrect code")
+		return
+	}
+
+	n, err := apiCfg.db.ConfirmUserTotp(ctx, database.ConfirmUserTotpParams{
+		Step:   step,
+		UserID: tok.UserID,
+	})
+	if err != nil {
+		log.Printf("Error confirming TOTP for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error confirming authenticator")
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already on")
+		return
+	}
+
+	codes := auth.MakeRecoveryCodes(recoveryCodeCount)
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+	if err := apiCfg.db.DeleteRecoveryCodes(ctx, tok.UserID); err != nil {
+		log.Printf("Error deleting old recovery codes for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error saving recovery codes")
+		return
+	}
+	err = apiCfg.db.CreateRecoveryCodes(ctx, database.CreateRecoveryCodesParams{
+		UserID:     tok.UserID,
+		CodeHashes: hashes,
+	})
+	if err != nil {
+		log.Printf("Error saving recovery codes for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error saving recovery codes")
+		return
+	}
+
+	type recoveryCodes struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	respondWithJSON(w, 200, recoveryCodes{RecoveryCodes: codes})
+}
+
+// handlerDisableTOTP turns two-factor authentication off. Like a login, it
+// needs a code from the authenticator app or a recovery code.
+func (apiCfg *apiConfig) handlerDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+	ctx := r.Context()
+
+	var req secondFactor
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		respondWithError(w, http.StatusBadRequest, "One of code or recovery_code is required")
+		return
+	}
+
+	totp, err := apiCfg.db.GetUserTotp(ctx, tok.UserID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !totp.ConfirmedAt.Valid) {
+		respondWithError(w, 404, "Two-factor authentication is not on")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting TOTP for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error turning off two-factor authentication")
+		return
+	}
+
+	ok, err := apiCfg.checkSecondFactor(ctx, tok.UserID, req)
+	if err != nil {
+		log.Printf("Error checking second factor for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error turning off two-factor authentication")
+		return
+	}
+	if !ok {
+		respondWithProblem(w, http.StatusUnauthorized, "Incorrect code")
+		return
+	}
+
+	if err := apiCfg.db.DeleteUserTotp(ctx, tok.UserID); err != nil {
+		log.Printf("Error deleting TOTP for user %s: %s", tok.UserID, err)
+		respondWithError(w, 500, "Error turning off two-factor authentication")
+		return
+	}
+	if err := apiCfg.db.DeleteRecoveryCodes(ctx, tok.UserID); err != nil {
+		log.Printf("Error deleting recovery codes for user %s: %s", tok.UserID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+func TestTOTPLogin(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	apiCfg.now = func() time.Time { return clock }
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	bearer := "Bearer " + walt.Token
+
+	var enrollment struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+	if resp := doJSON(t, srv, "POST", "/api/mfa/totp", bearer, nil, &enrollment); resp.StatusCode != http.StatusCreated || enrollment.Secret == "" {
+		t.Fatalf("POST /api/mfa/totp = %d %+v", resp.StatusCode, enrollment)
+	}
+	if want := auth.TOTPURI("Chirpy", "walt@example.com", enrollment.Secret); enrollment.OtpauthURI != want {
+		t.Errorf("otpauth_uri = %q, want %q", enrollment.OtpauthURI, want)
+	}
+	code := func() string {
+		c, err := auth.TOTPCode(enrollment.Secret, clock)
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+		return c
+	}
+
+	// Until it is confirmed, logging in still needs only the password.
+	creds := map[string]string{"email": "walt@example.com", "password": "04234"}
+	var login loginResponse
+	if doJSON(t, srv, "POST", "/api/login", "", creds, &login); login.Token == "" {
+		t.Fatalf("POST /api/login before confirming gave no token")
+	}
+
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if resp := doJSON(t, srv, "POST", "/api/mfa/totp/confirm", bearer, map[string]string{"code": "000000"}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /api/mfa/totp/confirm with a wrong code = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp := doJSON(t, srv, "POST", "/api/mfa/totp/confirm", bearer, map[string]string{"code": code()}, &confirmed); resp.StatusCode != http.StatusOK || len(confirmed.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("POST /api/mfa/totp/confirm = %d %+v", resp.StatusCode, confirmed)
+	}
+	if resp := doJSON(t, srv, "POST", "/api/mfa/totp", bearer, nil, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("POST /api/mfa/totp when on = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	startLogin := func() string {
+		t.Helper()
+		var challenge struct {
+			loginResponse
+			MFARequired bool   `json:"mfa_required"`
+			MFAToken    string `json:"mfa_token"`
+		}
+		doJSON(t, srv, "POST", "/api/login", "", creds, &challenge)
+		if !challenge.MFARequired || challenge.MFAToken == "" || challenge.Token != "" || challenge.RefreshToken != "" {
+			t.Fatalf("POST /api/login with TOTP on = %+v, want only an mfa_token", challenge)
+		}
+		return challenge.MFAToken
+	}
+
+	// The code used to confirm cannot be used again in the same period.
+	mfaToken := startLogin()
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		body     func() map[string]string
+		wantCode int
+	}{
+		{name: "Replayed code", body: func() map[string]string { return map[string]string{"mfa_token": mfaToken, "code": code()} }, wantCode: http.StatusUnauthorized},
+		{name: "Code and recovery code", body: func() map[string]string {
+			return map[string]string{"mfa_token": mfaToken, "code": code(), "recovery_code": confirmed.RecoveryCodes[0]}
+		}, wantCode: http.StatusBadRequest},
+		{name: "Unknown token", body: func() map[string]string { return map[string]string{"mfa_token": "nope", "code": code()} }, wantCode: http.StatusUnauthorized},
+		{name: "Next period's code", advance: auth.TOTPPeriod, body: func() map[string]string { return map[string]string{"mfa_token": mfaToken, "code": code()} }, wantCode: http.StatusOK},
+		{name: "Used token", body: func() map[string]string { return map[string]string{"mfa_token": mfaToken, "code": code()} }, wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = clock.Add(tt.advance)
+			var got loginResponse
+			resp := doJSON(t, srv, "POST", "/api/login/mfa", "", tt.body(), &got)
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("POST /api/login/mfa status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusOK && (got.Token == "" || got.RefreshToken == "" || got.ID != walt.ID) {
+				t.Errorf("POST /api/login/mfa = %+v, want tokens for %s", got, walt.ID)
+			}
+		})
+	}
+
+	// An mfa_token expires, and can only be tried so many times.
+	mfaToken = startLogin()
+	clock = clock.Add(mfaTokenTTL + time.Second)
+	if resp := doJSON(t, srv, "POST", "/api/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": code()}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /api/login/mfa with an expired token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	mfaToken = startLogin()
+	for i := 0; i < maxMFAAttempts; i++ {
+		doJSON(t, srv, "POST", "/api/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": "000000"}, nil)
+	}
+	if resp := doJSON(t, srv, "POST", "/api/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": code()}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /api/login/mfa after %d wrong codes = %d, want %d", maxMFAAttempts, resp.StatusCode, http.StatusUnauthorized)
+	}
+	// The wrong codes count against the account too.
+	if resp := doJSON(t, srv, "POST", "/api/login", "", creds, nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("POST /api/login after %d wrong codes = %d, want %d", maxMFAAttempts, resp.StatusCode, http.StatusTooManyRequests)
+	}
+	clock = clock.Add(time.Minute)
+
+	// Each recovery code works once.
+	for _, wantCode := range []int{http.StatusOK, http.StatusUnauthorized} {
+		body := map[string]string{"mfa_token": startLogin(), "recovery_code": confirmed.RecoveryCodes[1]}
+		if resp := doJSON(t, srv, "POST", "/api/login/mfa", "", body, nil); resp.StatusCode != wantCode {
+			t.Errorf("POST /api/login/mfa with recovery code = %d, want %d", resp.StatusCode, wantCode)
+		}
+	}
+	var status struct {
+		Enabled                bool  `json:"enabled"`
+		RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+	}
+	doJSON(t, srv, "GET", "/api/mfa/totp", bearer, nil, &status)
+	if !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Errorf("GET /api/mfa/totp = %+v, want enabled with %d recovery codes", status, recoveryCodeCount-1)
+	}
+
+	clock = clock.Add(auth.TOTPPeriod)
+	if resp := doJSON(t, srv, "DELETE", "/api/mfa/totp", bearer, map[string]string{"code": code()}, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE /api/mfa/totp = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	login = loginResponse{}
+	if doJSON(t, srv, "POST", "/api/login", "", creds, &login); login.Token == "" {
+		t.Errorf("POST /api/login after turning TOTP off gave no token")
+	}
+}
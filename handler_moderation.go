@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/moderation"
+)
+
+type BannedWordsResponse struct {
+	Policy moderation.Policy `json:"policy"`
+	Words  []string          `json:"words"`
+}
+
+type FlaggedChirpResponse struct {
+	Chirp     ChirpResponse `json:"chirp"`
+	Words     []string      `json:"words"`
+	FlaggedAt time.Time     `json:"flagged_at"`
+}
+
+// wordList returns the banned word list the admin endpoints edit. It writes
+// the error response itself and returns nil when the server's moderator
+// does not use one.
+func (apiCfg *apiConfig) wordList(w http.ResponseWriter) *moderation.WordList {
+	if apiCfg.bannedWords == nil {
+		respondWithError(w, 404, "This server does not moderate with a word list")
+	}
+	return apiCfg.bannedWords
+}
+
+func (apiCfg *apiConfig) handlerListBannedWords(w http.ResponseWriter, r *http.Request) {
+	list := apiCfg.wordList(w)
+	if list == nil {
+		return
+	}
+
+	respondWithJSON(w, 200, BannedWordsResponse{
+		Policy: list.Policy(),
+		Words:  list.Words(),
+	})
+}
+
+// handlerAddBannedWord bans {word}. Chirps already published are not
+// changed.
+func (apiCfg *apiConfig) handlerAddBannedWord(w http.ResponseWriter, r *http.Request) {
+	list := apiCfg.wordList(w)
+	if list == nil {
+		return
+	}
+
+	_, err := list.Add(r.Context(), r.PathValue("word"))
+	if errors.Is(err, moderation.ErrInvalidWord) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error banning word: %s", err)
+		respondWithError(w, 500, "Error banning word")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (apiCfg *apiConfig) handlerRemoveBannedWord(w http.ResponseWriter, r *http.Request) {
+	list := apiCfg.wordList(w)
+	if list == nil {
+		return
+	}
+
+	err := list.Remove(r.Context(), r.PathValue("word"))
+	if errors.Is(err, moderation.ErrInvalidWord) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error unbanning word: %s", err)
+		respondWithError(w, 500, "Error unbanning word")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListFlaggedChirps lists the chirps the flag policy has flagged for
+// review, most recently flagged first.
+func (apiCfg *apiConfig) handlerListFlaggedChirps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	page, err := parseFeedPageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	rows, err := apiCfg.db.ListFlaggedChirps(ctx, database.ListFlaggedChirpsParams{
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           int32(page.Limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error listing flagged chirps: %s", err)
+		respondWithError(w, 500, "Error listing flagged chirps")
+		return
+	}
+
+	rows = finishPage(w, r, page, rows, func(row database.ListFlaggedChirpsRow) pageCursor {
+		return pageCursor{CreatedAt: row.FlaggedAt, ID: row.Chirp.ID}
+	})
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	rendered, err := apiCfg.chirpResponses(ctx, chirps, uuid.NullUUID{})
+	if err != nil {
+		log.Printf("Error rendering flagged chirps: %s", err)
+		respondWithError(w, 500, "Error listing flagged chirps")
+		return
+	}
+
+	resp := make([]FlaggedChirpResponse, 0, len(rows))
+	for i, row := range rows {
+		resp = append(resp, FlaggedChirpResponse{
+			Chirp:     rendered[i],
+			Words:     row.Words,
+			FlaggedAt: row.FlaggedAt,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// handlerUnflagChirp clears a chirp's flag once it has been reviewed.
+func (apiCfg *apiConfig) handlerUnflagChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, ok := parseChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := apiCfg.db.UnflagChirp(r.Context(), chirpID); err != nil {
+		log.Printf("Error unflagging chirp: %s", err)
+		respondWithError(w, 500, "Error unflagging chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
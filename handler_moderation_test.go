@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mrbaker1917/chirpy/internal/moderation"
+)
+
+const adminAuth = "ApiKey test-admin-key"
+
+func TestModerationAdmin(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		auth     string
+		wantCode int
+	}{
+		{name: "No key", method: "GET", path: "/admin/moderation/words", auth: "", wantCode: 401},
+		{name: "Wrong key", method: "GET", path: "/admin/moderation/words", auth: "ApiKey nope", wantCode: 401},
+		{name: "User token", method: "GET", path: "/admin/moderation/words", auth: "Bearer " + walt.Token, wantCode: 403},
+		{name: "List", method: "GET", path: "/admin/moderation/words", auth: adminAuth, wantCode: 200},
+		{name: "Add", method: "PUT", path: "/admin/moderation/words/Heck", auth: adminAuth, wantCode: 204},
+		{name: "Add two words", method: "PUT", path: "/admin/moderation/words/oh%20heck", auth: adminAuth, wantCode: 400},
+		{name: "Remove", method: "DELETE", path: "/admin/moderation/words/fornax", auth: adminAuth, wantCode: 204},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doJSON(t, srv, tt.method, tt.path, tt.auth, nil, nil); resp.StatusCode != tt.wantCode {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	var words BannedWordsResponse
+	doJSON(t, srv, "GET", "/admin/moderation/words", adminAuth, nil, &words)
+	if words.Policy != moderation.PolicyReplace || len(words.Words) != 3 || words.Words[0] != "heck" {
+		t.Errorf("banned words = %+v, want replace policy with heck, kerfuffle and sharbert", words)
+	}
+
+	var chirp ChirpResponse
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "Heck! A KERFUFFLE, with fornax"}, &chirp)
+	if want := "****! A ****, with fornax"; chirp.Body != want {
+		t.Errorf("chirp body = %q, want %q", chirp.Body, want)
+	}
+}
+
+func TestModerationPolicies(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	setPolicy := func(policy moderation.Policy) {
+		t.Helper()
+		list := moderation.NewWordList(dbWordStore{db: apiCfg.db}, policy)
+		if err := list.Load(context.Background()); err != nil {
+			t.Fatalf("loading banned words: %v", err)
+		}
+		apiCfg.moderator, apiCfg.bannedWords = list, list
+	}
+
+	setPolicy(moderation.PolicyReject)
+	if resp := doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "what a kerfuffle"}, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("rejected chirp status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	setPolicy(moderation.PolicyFlag)
+	var flagged, clean ChirpResponse
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "what a kerfuffle"}, &flagged)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "all fine"}, &clean)
+	if flagged.Body != "what a kerfuffle" {
+		t.Errorf("flagged chirp body = %q, want it unchanged", flagged.Body)
+	}
+
+	var flags []FlaggedChirpResponse
+	if resp := doJSON(t, srv, "GET", "/admin/moderation/flagged", adminAuth, nil, &flags); resp.StatusCode != 200 {
+		t.Fatalf("GET /admin/moderation/flagged status = %d, want 200", resp.StatusCode)
+	}
+	if len(flags) != 1 || flags[0].Chirp.ID != flagged.ID || len(flags[0].Words) != 1 || flags[0].Words[0] != "kerfuffle" {
+		t.Fatalf("flagged chirps = %+v, want just %v for kerfuffle", flags, flagged.ID)
+	}
+
+	path := "/admin/moderation/flagged/" + flagged.ID.String()
+	if resp := doJSON(t, srv, "DELETE", path, adminAuth, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE %s status = %d, want %d", path, resp.StatusCode, http.StatusNoContent)
+	}
+	doJSON(t, srv, "GET", "/admin/moderation/flagged", adminAuth, nil, &flags)
+	if len(flags) != 0 {
+		t.Errorf("flagged chirps after unflagging = %+v, want none", flags)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type NotificationActor struct {
+	ID     uuid.UUID `json:"id"`
+	Handle string    `json:"handle"`
+}
+
+type NotificationResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	Kind      string            `json:"kind"`
+	CreatedAt time.Time         `json:"created_at"`
+	Read      bool              `json:"read"`
+	Actor     NotificationActor `json:"actor"`
+	// Chirp is the mention or reply, or the chirp that was liked. It is
+	// null for follows.
+	Chirp *ChirpResponse `json:"chirp"`
+}
+
+// handlerListNotifications returns the requester's notifications, newest
+// first. With unread=true only the ones not yet marked read are listed.
+func (apiCfg *apiConfig) handlerListNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := requestPrincipal(r).UserID
+
+	q := r.URL.Query()
+	page, err := parseFeedPageParams(q)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	unreadOnly := false
+	if u := q.Get("unread"); u != "" {
+		unreadOnly, err = strconv.ParseBool(u)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "unread must be true or false")
+			return
+		}
+	}
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	rows, err := apiCfg.db.ListNotifications(ctx, database.ListNotificationsParams{
+		UserID:          userID,
+		UnreadOnly:      unreadOnly,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           int32(page.Limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error listing notifications: %s", err)
+		respondWithError(w, 500, "Error getting notifications")
+		return
+	}
+
+	rows = finishPage(w, r, page, rows, func(row database.ListNotificationsRow) pageCursor {
+		return pageCursor{CreatedAt: row.Notification.CreatedAt, ID: row.Notification.ID}
+	})
+
+	var chirpIDs []uuid.UUID
+	for _, row := range rows {
+		if row.Notification.ChirpID.Valid {
+			chirpIDs = append(chirpIDs, row.Notification.ChirpID.UUID)
+		}
+	}
+	var found []database.Chirp
+	if len(chirpIDs) > 0 {
+		found, err = apiCfg.db.GetChirpsByIds(ctx, chirpIDs)
+		if err != nil {
+			log.Printf("Error loading notification chirps: %s", err)
+			respondWithError(w, 500, "Error getting notifications")
+			return
+		}
+	}
+	resps, err := apiCfg.chirpResponses(ctx, found, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error rendering notification chirps: %s", err)
+		respondWithError(w, 500, "Error getting notifications")
+		return
+	}
+	chirps := make(map[uuid.UUID]ChirpResponse, len(resps))
+	for _, chirp := range resps {
+		chirps[chirp.ID] = chirp
+	}
+
+	resp := []NotificationResponse{}
+	for _, row := range rows {
+		n := NotificationResponse{
+			ID:        row.Notification.ID,
+			Kind:      row.Notification.Kind,
+			CreatedAt: row.Notification.CreatedAt,
+			Read:      row.Notification.ReadAt.Valid,
+			Actor: NotificationActor{
+				ID:     row.Notification.ActorID,
+				Handle: row.ActorHandle,
+			},
+		}
+		if chirp, ok := chirps[row.Notification.ChirpID.UUID]; ok && row.Notification.ChirpID.Valid {
+			n.Chirp = &chirp
+		}
+		resp = append(resp, n)
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// handlerMarkNotificationsRead marks the notifications listed in ids as
+// read, or all of the requester's notifications when there is no body or
+// no ids.
+func (apiCfg *apiConfig) handlerMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := requestPrincipal(r).UserID
+
+	type reqBody struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+	reqBdy := reqBody{}
+	err := json.NewDecoder(r.Body).Decode(&reqBdy)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	if reqBdy.IDs == nil {
+		err = apiCfg.db.MarkAllNotificationsRead(ctx, userID)
+	} else {
+		err = apiCfg.db.MarkNotificationsRead(ctx, database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    reqBdy.IDs,
+		})
+	}
+	if err != nil {
+		log.Printf("Error marking notifications read: %s", err)
+		respondWithError(w, 500, "Error marking notifications read")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
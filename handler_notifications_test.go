@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func notificationKinds(ns []NotificationResponse) string {
+	kinds := make([]string, 0, len(ns))
+	for _, n := range ns {
+		kind := n.Kind + ":" + n.Actor.Handle
+		if !n.Read {
+			kind += "*"
+		}
+		kinds = append(kinds, kind)
+	}
+	return strings.Join(kinds, ",")
+}
+
+func TestCreateUserHandles(t *testing.T) {
+	_, srv := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantCode   int
+		wantHandle string
+	}{
+		{name: "Chosen", body: map[string]string{"email": "walt@example.com", "password": "04234", "handle": "@Heisenberg"}, wantCode: 201, wantHandle: "heisenberg"},
+		{name: "Chosen and taken", body: map[string]string{"email": "walter@example.com", "password": "04234", "handle": "heisenberg"}, wantCode: 409},
+		{name: "Invalid", body: map[string]string{"email": "walter@example.com", "password": "04234", "handle": "not a handle"}, wantCode: 400},
+		{name: "From email", body: map[string]string{"email": "jesse@example.com", "password": "12345"}, wantCode: 201, wantHandle: "jesse"},
+		{name: "Taken email", body: map[string]string{"email": "jesse@example.com", "password": "12345", "handle": "pinkman"}, wantCode: 409},
+		{name: "No email", body: map[string]string{"password": "12345", "handle": "pinkman"}, wantCode: 400},
+		{name: "Malformed email", body: map[string]string{"email": "jesse at example.com", "password": "12345", "handle": "pinkman"}, wantCode: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user User
+			resp := doJSON(t, srv, "POST", "/api/users", "", tt.body, &user)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if tt.wantHandle != "" && user.Handle != tt.wantHandle {
+				t.Errorf("handle = %q, want %q", user.Handle, tt.wantHandle)
+			}
+		})
+	}
+
+	// A suggested handle that is taken gets digits added instead of failing.
+	var user User
+	doJSON(t, srv, "POST", "/api/users", "", map[string]string{"email": "jesse@example.org", "password": "12345"}, &user)
+	if !strings.HasPrefix(user.Handle, "jesse") || user.Handle == "jesse" || !validHandle(user.Handle) {
+		t.Errorf("handle for second jesse = %q, want jesse plus digits", user.Handle)
+	}
+}
+
+func TestMentionsAndNotifications(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+	saul := createAndLogin(t, srv, "saul@example.com", "54321")
+
+	var chirp ChirpResponse
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "cook with @Jesse and @nobody"}, &chirp)
+	want := []MentionEntity{{Start: 10, End: 16, UserID: jesse.ID}}
+	if len(chirp.Entities.Mentions) != 1 || chirp.Entities.Mentions[0] != want[0] {
+		t.Errorf("mentions = %+v, want %+v", chirp.Entities.Mentions, want)
+	}
+
+	// Jesse replies mentioning Walt, who should get one notification for it.
+	var reply ChirpResponse
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]interface{}{"body": "yeah @walt", "in_reply_to": chirp.ID}, &reply)
+	doJSON(t, srv, "PUT", "/api/chirps/"+chirp.ID.String()+"/like", "Bearer "+jesse.Token, nil, nil)
+	doJSON(t, srv, "PUT", "/api/chirps/"+chirp.ID.String()+"/like", "Bearer "+walt.Token, nil, nil)
+	doJSON(t, srv, "POST", "/api/users/"+walt.ID.String()+"/follow", "Bearer "+jesse.Token, nil, nil)
+
+	// Saul is blocked, so his follow and mention make no notifications.
+	doJSON(t, srv, "POST", "/api/users/"+saul.ID.String()+"/block", "Bearer "+walt.Token, nil, nil)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+saul.Token, map[string]string{"body": "better call @walt"}, nil)
+
+	var notifications []NotificationResponse
+	if resp := doJSON(t, srv, "GET", "/api/notifications", "Bearer "+walt.Token, nil, &notifications); resp.StatusCode != 200 {
+		t.Fatalf("GET /api/notifications status = %d, want 200", resp.StatusCode)
+	}
+	if got := notificationKinds(notifications); got != "follow:jesse*,like:jesse*,reply:jesse*" {
+		t.Errorf("walt's notifications = %q", got)
+	}
+	if len(notifications) == 3 && (notifications[2].Chirp == nil || notifications[2].Chirp.ID != reply.ID || notifications[0].Chirp != nil) {
+		t.Errorf("notification chirps = %+v, %+v; want the reply and none for the follow", notifications[2].Chirp, notifications[0].Chirp)
+	}
+
+	doJSON(t, srv, "GET", "/api/notifications", "Bearer "+jesse.Token, nil, &notifications)
+	if got := notificationKinds(notifications); got != "mention:walt*" {
+		t.Errorf("jesse's notifications = %q", got)
+	}
+
+	// Mark one read, then the rest.
+	doJSON(t, srv, "GET", "/api/notifications", "Bearer "+walt.Token, nil, &notifications)
+	readOne := map[string][]uuid.UUID{"ids": {notifications[1].ID}}
+	if resp := doJSON(t, srv, "POST", "/api/notifications/read", "Bearer "+walt.Token, readOne, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST /api/notifications/read status = %d, want 204", resp.StatusCode)
+	}
+	doJSON(t, srv, "GET", "/api/notifications?unread=true", "Bearer "+walt.Token, nil, &notifications)
+	if got := notificationKinds(notifications); got != "follow:jesse*,reply:jesse*" {
+		t.Errorf("unread after marking one = %q", got)
+	}
+	doJSON(t, srv, "POST", "/api/notifications/read", "Bearer "+walt.Token, nil, nil)
+	doJSON(t, srv, "GET", "/api/notifications", "Bearer "+walt.Token, nil, &notifications)
+	if got := notificationKinds(notifications); got != "follow:jesse,like:jesse,reply:jesse" {
+		t.Errorf("notifications after marking all = %q", got)
+	}
+
+	if resp := doJSON(t, srv, "GET", "/api/notifications", "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /api/notifications without token status = %d, want 401", resp.StatusCode)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/notifications?unread=maybe", "Bearer "+walt.Token, nil, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /api/notifications?unread=maybe status = %d, want 400", resp.StatusCode)
+	}
+}
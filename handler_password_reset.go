@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/mailer"
+)
+
+const (
+	// passwordResetTTL is how long a password reset email stays good for.
+	passwordResetTTL = time.Hour
+	// passwordResetSendTimeout bounds making and sending a reset email,
+	// which happens after the response.
+	passwordResetSendTimeout = 30 * time.Second
+)
+
+// handlerForgotPassword emails a password reset token to {"email": ...}.
+// It responds the same way whether or not there is an account with that
+// email, so it cannot be used to find out who has one: the lookup and the
+// email happen after the response in either case, so that how long it takes
+// does not tell either.
+func (apiCfg *apiConfig) handlerForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if apiCfg.mailer == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Password reset is not available")
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	apiCfg.background.Go(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetSendTimeout)
+		defer cancel()
+		if err := apiCfg.sendPasswordReset(ctx, req.Email); err != nil {
+			log.Printf("Error sending password reset email: %s", err)
+		}
+	})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendPasswordReset emails a new password reset token to the user with
+// email, if there is one.
+func (apiCfg *apiConfig) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := apiCfg.db.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return fmt.Errorf("making token: %w", err)
+	}
+	err = apiCfg.db.CreatePasswordReset(ctx, database.CreatePasswordResetParams{
+		TokenHash: auth.HashRefreshToken(token),
+		UserID:    user.ID,
+		ExpiresAt: apiCfg.now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("saving password reset: %w", err)
+	}
+
+	if err := apiCfg.mailer.Send(ctx, apiCfg.passwordResetEmail(user.Email, token)); err != nil {
+		return fmt.Errorf("user %s: %w", user.ID, err)
+	}
+	return nil
+}
+
+func (apiCfg *apiConfig) passwordResetEmail(to, token string) mailer.Message {
+	how := fmt.Sprintf("send this token with your new password to POST /api/password/reset:\n\n%s", token)
+	if apiCfg.passwordResetURL != "" {
+		how = fmt.Sprintf("follow this link:\n\n%s?token=%s", apiCfg.passwordResetURL, url.QueryEscape(token))
+	}
+	return mailer.Message{
+		To:      to,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account. "+
+			"To choose a new one within the next hour, %s\n\n"+
+			"If it was not you, you can ignore this email: your password has not changed.\n", how),
+	}
+}
+
+// handlerResetPassword sets a new password for {"token": ..., "password": ...}
+// where token came from a password reset email. It signs the user out
+// everywhere, revokes every access token and API key they hold, and
+// cancels any login waiting for a second factor.
+func (apiCfg *apiConfig) handlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+	// The password is checked before the token is used up, so a user
+	// whose password is refused can try another.
+	if !apiCfg.checkPassword(w, r, req.Password) {
+		return
+	}
+
+	reset, err := apiCfg.db.UsePasswordReset(ctx, database.UsePasswordResetParams{
+		TokenHash: auth.HashRefreshToken(req.Token),
+		Now:       apiCfg.now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithProblem(w, http.StatusUnauthorized, "The reset token is not valid or has expired")
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking password reset: %s", err)
+		respondWithError(w, 500, "Error resetting password")
+		return
+	}
+
+	hashed_password, err := apiCfg.passwordParams.Hash(req.Password)
+	if err != nil {
+		log.Printf("Error hashing password: %s", err)
+		respondWithError(w, 500, "Error resetting password")
+		return
+	}
+	err = apiCfg.db.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:             reset.UserID,
+		HashedPassword: hashed_password,
+	})
+	if err != nil {
+		log.Printf("Error updating password for user %s: %s", reset.UserID, err)
+		respondWithError(w, 500, "Error resetting password")
+		return
+	}
+
+	// Whoever knew the old password, or asked for another reset, is locked
+	// out along with every device.
+	if err := apiCfg.db.DeletePasswordResets(ctx, reset.UserID); err != nil {
+		log.Printf("Error deleting password resets for user %s: %s", reset.UserID, err)
+	}
+	if err := apiCfg.db.RevokeAllSessions(ctx, reset.UserID); err != nil {
+		log.Printf("Error revoking sessions: %s", err)
+		respondWithError(w, 500, "Error resetting password")
+		return
+	}
+	if _, err := apiCfg.tokenVersions.bump(ctx, reset.UserID); err != nil {
+		log.Printf("Error revoking access tokens: %s", err)
+		respondWithError(w, 500, "Error resetting password")
+		return
+	}
+	if err := apiCfg.db.DeleteUserApiKeys(ctx, reset.UserID); err != nil {
+		log.Printf("Error deleting API keys: %s", err)
+		respondWithError(w, 500, "Error resetting password")
+		return
+	}
+	if err := apiCfg.db.DeleteUserMfaChallenges(ctx, reset.UserID); err != nil {
+		log.Printf("Error deleting MFA challenges: %s", err)
+		respondWithError(w, 500, "Error resetting password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
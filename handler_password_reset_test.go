@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/mailer"
+)
+
+// testMailer keeps the messages it is asked to send.
+type testMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *testMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// lastToken returns the token in the last email sent, which follows the
+// line naming the endpoint to send it to.
+func (m *testMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatalf("no email was sent")
+	}
+	body := m.sent[len(m.sent)-1].Body
+	_, after, ok := strings.Cut(body, ":\n\n")
+	if !ok {
+		t.Fatalf("email body %q has no token", body)
+	}
+	return strings.Fields(after)[0]
+}
+
+func TestPasswordReset(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	apiCfg.now = func() time.Time { return clock }
+	mail := apiCfg.mailer.(*testMailer)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	var bot ApiKeyResponse
+	doJSON(t, srv, "POST", "/api/keys", "Bearer "+walt.Token, map[string]string{"name": "bot", "scope": "chirps:read"}, &bot)
+	// A login waiting for a second factor.
+	challenge := database.CreateMfaChallengeParams{TokenHash: "challenge", UserID: walt.ID, ExpiresAt: clock.Add(2 * passwordResetTTL)}
+	if err := apiCfg.db.CreateMfaChallenge(context.Background(), challenge); err != nil {
+		t.Fatalf("CreateMfaChallenge() error = %v", err)
+	}
+
+	sent := len(mail.sent)
+	resp := doJSON(t, srv, "POST", "/api/password/forgot", "", map[string]string{"email": "jesse@example.com"}, nil)
+	apiCfg.background.Wait()
+	if resp.StatusCode != http.StatusAccepted || len(mail.sent) != sent {
+		t.Fatalf("POST /api/password/forgot for an unknown email = %d with %d emails, want %d and none", resp.StatusCode, len(mail.sent)-sent, http.StatusAccepted)
+	}
+	forgot := func() string {
+		t.Helper()
+		if resp := doJSON(t, srv, "POST", "/api/password/forgot", "", map[string]string{"email": "walt@example.com"}, nil); resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("POST /api/password/forgot = %d, want %d", resp.StatusCode, http.StatusAccepted)
+		}
+		// The email is sent after the response.
+		apiCfg.background.Wait()
+		if to := mail.sent[len(mail.sent)-1].To; to != "walt@example.com" {
+			t.Fatalf("reset email sent to %q, want walt@example.com", to)
+		}
+		return mail.lastToken(t)
+	}
+
+	expired := forgot()
+	clock = clock.Add(passwordResetTTL)
+	token := forgot()
+	other := forgot()
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		wantCode int
+	}{
+		{name: "Expired token", token: expired, password: "heisenberg", wantCode: http.StatusUnauthorized},
+		{name: "Unknown token", token: "nope", password: "heisenberg", wantCode: http.StatusUnauthorized},
+		{name: "Short password", token: token, password: "1234", wantCode: http.StatusBadRequest},
+		{name: "Valid token", token: token, password: "heisenberg", wantCode: http.StatusNoContent},
+		{name: "Used token", token: token, password: "heisenberg", wantCode: http.StatusUnauthorized},
+		{name: "Other outstanding token", token: other, password: "heisenberg", wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doJSON(t, srv, "POST", "/api/password/reset", "", map[string]string{"token": tt.token, "password": tt.password}, nil)
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("POST /api/password/reset status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	if resp := doJSON(t, srv, "POST", "/api/refresh", "Bearer "+walt.RefreshToken, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after reset = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/sessions", "Bearer "+walt.Token, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /api/sessions with an old access token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/timeline", "ApiKey "+bot.Key, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /api/timeline with an API key made before the reset = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	_, err := apiCfg.db.AttemptMfaChallenge(context.Background(), database.AttemptMfaChallengeParams{TokenHash: challenge.TokenHash, Now: clock, MaxAttempts: maxMFAAttempts})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("AttemptMfaChallenge() after reset error = %v, want sql.ErrNoRows", err)
+	}
+	for password, wantCode := range map[string]int{"04234": http.StatusUnauthorized, "heisenberg": http.StatusOK} {
+		if resp := doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "walt@example.com", "password": password}, nil); resp.StatusCode != wantCode {
+			t.Errorf("POST /api/login with %q after reset = %d, want %d", password, resp.StatusCode, wantCode)
+		}
+	}
+
+	apiCfg.mailer = nil
+	if resp := doJSON(t, srv, "POST", "/api/password/forgot", "", map[string]string{"email": "walt@example.com"}, nil); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("POST /api/password/forgot without a mailer = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+var (
+	errChirpNotFound = errors.New("chirp not found")
+	errRepostBlocked = errors.New("author has blocked the reposter")
+)
+
+// repostedChirp returns the chirp that reposting chirpID reposts: chirpID
+// itself, or the chirp it points at if it is a rechirp.
+func (apiCfg *apiConfig) repostedChirp(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := apiCfg.db.GetChirpById(ctx, chirpID)
+	if err == nil && chirp.Kind == chirpKindRechirp {
+		chirp, err = apiCfg.db.GetChirpById(ctx, chirp.RefChirpID.UUID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Chirp{}, errChirpNotFound
+	}
+	return chirp, err
+}
+
+// repostTarget returns the chirp userID would be rechirping or quoting when
+// they repost chirpID, as repostedChirp does. It fails with
+// errRepostBlocked if that chirp's author has blocked userID.
+func (apiCfg *apiConfig) repostTarget(ctx context.Context, chirpID, userID uuid.UUID) (database.Chirp, error) {
+	chirp, err := apiCfg.repostedChirp(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	blocked, err := apiCfg.db.IsBlocked(ctx, database.IsBlockedParams{
+		BlockerID: chirp.UserID,
+		BlockedID: userID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if blocked {
+		return database.Chirp{}, errRepostBlocked
+	}
+	return chirp, nil
+}
+
+// handlerRechirp reposts a chirp for the requester. Rechirping the same chirp
+// again returns the existing rechirp.
+func (apiCfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := requestPrincipal(r).UserID
+
+	chirpID, ok := parseChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	original, err := apiCfg.repostTarget(ctx, chirpID, userID)
+	if errors.Is(err, errChirpNotFound) {
+		respondWithError(w, 404, "Chirp not found.")
+		return
+	}
+	if errors.Is(err, errRepostBlocked) {
+		respondWithError(w, 403, "You cannot rechirp this chirp")
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up chirp to rechirp: %s", err)
+		respondWithError(w, 500, "Error rechirping")
+		return
+	}
+	ref := uuid.NullUUID{UUID: original.ID, Valid: true}
+
+	status := http.StatusCreated
+	rechirp, err := apiCfg.db.CreateChirp(ctx, database.CreateChirpParams{
+		UserID:     userID,
+		Kind:       chirpKindRechirp,
+		RefChirpID: ref,
+	})
+	if isUniqueViolation(err, "chirps_user_id_rechirp_idx") {
+		status = http.StatusOK
+		rechirp, err = apiCfg.db.GetRechirp(ctx, database.GetRechirpParams{
+			UserID:     userID,
+			RefChirpID: ref,
+		})
+	}
+	if isForeignKeyViolation(err, "chirps_ref_chirp_id_fkey") {
+		respondWithError(w, 404, "Chirp not found.")
+		return
+	}
+	if err != nil {
+		log.Printf("Error rechirping: %s", err)
+		respondWithError(w, 500, "Error rechirping")
+		return
+	}
+
+	resps, err := apiCfg.chirpResponses(ctx, []database.Chirp{rechirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error rendering rechirp: %s", err)
+		respondWithError(w, 500, "Error rechirping")
+		return
+	}
+	respondWithJSON(w, status, resps[0])
+}
+
+// handlerUndoRechirp removes the requester's rechirp of a chirp, if there is
+// one. As with rechirping, the chirp can be named by the ID of a rechirp of
+// it.
+func (apiCfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := requestPrincipal(r).UserID
+
+	chirpID, ok := parseChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	original, err := apiCfg.repostedChirp(ctx, chirpID)
+	if errors.Is(err, errChirpNotFound) {
+		// Deleting a chirp deletes its rechirps, so there is nothing to
+		// undo.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up chirp to undo rechirp: %s", err)
+		respondWithError(w, 500, "Error undoing rechirp")
+		return
+	}
+
+	err = apiCfg.db.DeleteRechirp(ctx, database.DeleteRechirpParams{
+		UserID:     userID,
+		RefChirpID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error undoing rechirp: %s", err)
+		respondWithError(w, 500, "Error undoing rechirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRechirpsAndQuotes(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+
+	var original ChirpResponse
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "say my name"}, &original)
+	rechirpPath := "/api/chirps/" + original.ID.String() + "/rechirp"
+
+	var rechirp ChirpResponse
+	if resp := doJSON(t, srv, "POST", rechirpPath, "Bearer "+jesse.Token, nil, &rechirp); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST %s status = %d, want %d", rechirpPath, resp.StatusCode, http.StatusCreated)
+	}
+	if rechirp.Kind != "rechirp" || rechirp.Original == nil || rechirp.Original.ID != original.ID {
+		t.Fatalf("rechirp = %+v, want kind rechirp embedding %v", rechirp, original.ID)
+	}
+	var again ChirpResponse
+	if resp := doJSON(t, srv, "POST", rechirpPath, "Bearer "+jesse.Token, nil, &again); resp.StatusCode != http.StatusOK || again.ID != rechirp.ID {
+		t.Errorf("second rechirp status = %d, id = %v, want 200 and %v", resp.StatusCode, again.ID, rechirp.ID)
+	}
+
+	// Undoing by the rechirp's own ID, as timelines show it, undoes it.
+	undoPath := "/api/chirps/" + rechirp.ID.String() + "/rechirp"
+	if resp := doJSON(t, srv, "DELETE", undoPath, "Bearer "+jesse.Token, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE %s status = %d, want %d", undoPath, resp.StatusCode, http.StatusNoContent)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/chirps/"+rechirp.ID.String(), "", nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET rechirp after undoing it status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := doJSON(t, srv, "POST", rechirpPath, "Bearer "+jesse.Token, nil, &rechirp); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST %s after undoing status = %d, want %d", rechirpPath, resp.StatusCode, http.StatusCreated)
+	}
+
+	// A quote without a body would be a second rechirp.
+	if resp := doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]interface{}{"body": " ", "quote_of": original.ID}, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("quote without a body status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	var quote ChirpResponse
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]interface{}{"body": "heisenberg", "quote_of": original.ID}, &quote)
+	if quote.Kind != "quote" || quote.Original == nil || quote.Original.RechirpCount != 1 {
+		t.Errorf("quote = %+v, want kind quote embedding a chirp with 1 rechirp", quote)
+	}
+
+	// Blocking removes the rechirp, detaches the quote and stops new reposts.
+	blockPath := "/api/users/" + jesse.ID.String() + "/block"
+	if resp := doJSON(t, srv, "POST", blockPath, "Bearer "+walt.Token, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST %s status = %d, want %d", blockPath, resp.StatusCode, http.StatusNoContent)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/chirps/"+rechirp.ID.String(), "", nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET rechirp after block status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	doJSON(t, srv, "GET", "/api/chirps/"+quote.ID.String(), "", nil, &quote)
+	if quote.Original != nil {
+		t.Errorf("quote original after block = %+v, want null", quote.Original)
+	}
+	if resp := doJSON(t, srv, "POST", rechirpPath, "Bearer "+jesse.Token, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("rechirp while blocked status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	// Deleting the original takes rechirps with it and leaves quotes up.
+	doJSON(t, srv, "DELETE", blockPath, "Bearer "+walt.Token, nil, nil)
+	doJSON(t, srv, "POST", rechirpPath, "Bearer "+jesse.Token, nil, &rechirp)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]interface{}{"body": "again", "quote_of": original.ID}, &quote)
+	doJSON(t, srv, "DELETE", "/api/chirps/"+original.ID.String(), "Bearer "+walt.Token, nil, nil)
+	if resp := doJSON(t, srv, "GET", "/api/chirps/"+rechirp.ID.String(), "", nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET rechirp after delete status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := doJSON(t, srv, "GET", "/api/chirps/"+quote.ID.String(), "", nil, &quote); resp.StatusCode != http.StatusOK || quote.Original != nil {
+		t.Errorf("GET quote after delete status = %d, original = %+v, want 200 and null", resp.StatusCode, quote.Original)
+	}
+}
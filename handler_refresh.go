@@ -14,35 +14,51 @@ func (apiCfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request)
 
 	r_token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, 401, "Could not find token in header")
+		respondWithProblem(w, http.StatusUnauthorized, "Could not find token in header")
 		return
 	}
 
 	if r_token == "" {
-		respondWithError(w, 401, "Could not find token in header")
+		respondWithProblem(w, http.StatusUnauthorized, "Could not find token in header")
 		return
 	}
 
-	user, err := apiCfg.db.GetUserFromRefreshToken(ctx, r_token)
+	// Each refresh token is good for exactly one refresh; it is swapped for
+	// a new one in the same family.
+	tokenHash := auth.HashRefreshToken(r_token)
+	old, err := apiCfg.db.ConsumeRefreshToken(ctx, tokenHash)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, 401, "Unauthorized")
+			apiCfg.detectRefreshTokenReuse(ctx, tokenHash)
+			respondWithProblem(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 		respondWithError(w, 500, "Error looking up refresh token")
 		return
 	}
 
-	token, err := auth.MakeJWT(user.ID, apiCfg.secret)
+	token, err := apiCfg.makeAccessToken(ctx, auth.AccessToken{
+		UserID:    old.UserID,
+		SessionID: old.FamilyID,
+		Scopes:    auth.UserScopes,
+	}, accessTokenTTL)
 	if err != nil {
 		log.Printf("Error acquiring JWT: %s", err)
 		respondWithError(w, 500, "Error acquiring JWT")
 		return
 	}
 
+	newToken, err := apiCfg.issueRefreshToken(r, old.UserID, old.FamilyID, sql.NullString{String: old.TokenHash, Valid: true})
+	if err != nil {
+		log.Printf("Error saving refresh token: %s", err)
+		respondWithError(w, 500, "Error saving refresh token")
+		return
+	}
+
 	type resp struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	respondWithJSON(w, 200, resp{Token: token})
+	respondWithJSON(w, 200, resp{Token: token, RefreshToken: newToken})
 }
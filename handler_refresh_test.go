@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/memstore"
+)
+
+type refreshResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func refresh(t *testing.T, srv *httptest.Server, token string) (int, refreshResponse) {
+	t.Helper()
+	var out refreshResponse
+	resp := doJSON(t, srv, "POST", "/api/refresh", "Bearer "+token, nil, &out)
+	return resp.StatusCode, out
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	_, srv := newTestServer(t)
+	login := createAndLogin(t, srv, "walt@example.com", "04234")
+	var other loginResponse
+	if resp := doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "walt@example.com", "password": "04234"}, &other); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /api/login status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	code, first := refresh(t, srv, login.RefreshToken)
+	if code != http.StatusOK || first.Token == "" || first.RefreshToken == "" {
+		t.Fatalf("POST /api/refresh status = %d, response = %+v", code, first)
+	}
+	if first.RefreshToken == login.RefreshToken {
+		t.Fatalf("POST /api/refresh returned the same refresh token")
+	}
+	code, second := refresh(t, srv, first.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("POST /api/refresh with rotated token status = %d, want %d", code, http.StatusOK)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{name: "Replayed original token", token: login.RefreshToken, wantCode: http.StatusUnauthorized},
+		{name: "Newest token in the reused family", token: second.RefreshToken, wantCode: http.StatusUnauthorized},
+		{name: "Unknown token", token: "not-a-token", wantCode: http.StatusUnauthorized},
+		{name: "Other login is unaffected", token: other.RefreshToken, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := refresh(t, srv, tt.token); code != tt.wantCode {
+				t.Errorf("POST /api/refresh status = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	clock := time.Now()
+	apiCfg.db.(*memstore.Store).Now = func() time.Time { return clock }
+	login := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	code, first := refresh(t, srv, login.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("POST /api/refresh status = %d, want %d", code, http.StatusOK)
+	}
+	// A second tab refreshing with the same token a moment later is turned
+	// away without signing the first tab out.
+	if code, _ := refresh(t, srv, login.RefreshToken); code != http.StatusUnauthorized {
+		t.Fatalf("POST /api/refresh with just-rotated token status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	code, second := refresh(t, srv, first.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("POST /api/refresh with replacement status = %d, want %d", code, http.StatusOK)
+	}
+
+	// Once the grace window has passed, the same replay revokes the family.
+	clock = clock.Add(11 * time.Second)
+	if code, _ := refresh(t, srv, first.RefreshToken); code != http.StatusUnauthorized {
+		t.Fatalf("POST /api/refresh with stale token status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code, _ := refresh(t, srv, second.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after reuse status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestRefreshTokensStoredHashed(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	login := createAndLogin(t, srv, "walt@example.com", "04234")
+	ctx := context.Background()
+
+	if _, err := apiCfg.db.GetRefreshToken(ctx, login.RefreshToken); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetRefreshToken(raw token) error = %v, want sql.ErrNoRows", err)
+	}
+	rt, err := apiCfg.db.GetRefreshToken(ctx, auth.HashRefreshToken(login.RefreshToken))
+	if err != nil {
+		t.Fatalf("GetRefreshToken(token hash) error = %v", err)
+	}
+	if rt.TokenHash == login.RefreshToken {
+		t.Errorf("stored token hash = raw token")
+	}
+}
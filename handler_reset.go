@@ -19,6 +19,7 @@ func (apiCfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 500, "Error deleting all users")
 		return
 	}
+	apiCfg.tokenVersions.reset()
 
 	apiCfg.fileserverHits.Store(0)
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
@@ -11,16 +11,16 @@ func (apiCfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 
 	r_token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, 401, "Could not find token in header")
+		respondWithProblem(w, http.StatusUnauthorized, "Could not find token in header")
 		return
 	}
 
 	if r_token == "" {
-		respondWithError(w, 401, "Could not find token in header")
+		respondWithProblem(w, http.StatusUnauthorized, "Could not find token in header")
 		return
 	}
 
-	err = apiCfg.db.RevokeRefreshToken(ctx, r_token)
+	err = apiCfg.db.RevokeRefreshToken(ctx, auth.HashRefreshToken(r_token))
 	if err != nil {
 		respondWithError(w, 500, "Error revoking refresh token")
 		return
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// maxSearchOffset bounds how deep clients can page into search results,
+// since every page has to rank everything before it.
+const maxSearchOffset = 1000
+
+// handlerSearchChirps runs a full-text search over chirp bodies, best match
+// first. q takes web search syntax: "quoted phrases", -excluded words and OR.
+// Results can be narrowed with author_id and an RFC 3339 since/until range,
+// and are paged with limit and offset; the Link header carries the next page.
+func (apiCfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	query := q.Get("q")
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	limit, err := parsePageLimit(q)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	offset := 0
+	if o := q.Get("offset"); o != "" {
+		offset, err = strconv.Atoi(o)
+		if err != nil || offset < 0 || offset > maxSearchOffset {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("offset must be between 0 and %d", maxSearchOffset))
+			return
+		}
+	}
+
+	var authorID uuid.NullUUID
+	if a := q.Get("author_id"); a != "" {
+		uAuthorID, err := uuid.Parse(a)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "we encountered an error parsing author_id")
+			return
+		}
+		authorID = uuid.NullUUID{UUID: uAuthorID, Valid: true}
+	}
+
+	since, err := parseTimeParam(q, "since")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	until, err := parseTimeParam(q, "until")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := apiCfg.db.SearchChirps(ctx, database.SearchChirpsParams{
+		Query:    query,
+		AuthorID: authorID,
+		Since:    since,
+		Until:    until,
+		Limit:    int32(limit + 1),
+		Offset:   int32(offset),
+	})
+	if err != nil {
+		log.Printf("Error searching chirps: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "Error searching chirps")
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		if next := offset + limit; next <= maxSearchOffset {
+			q.Set("offset", strconv.Itoa(next))
+			u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+			w.Header().Set("Link", fmt.Sprintf("<%s>; rel=%q", u.String(), "next"))
+		}
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	response_chirps, err := apiCfg.chirpResponses(ctx, chirps, apiCfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error rendering search results: %s", err)
+		respondWithError(w, http.StatusInternalServerError, "Error searching chirps")
+		return
+	}
+	respondWithJSON(w, 200, response_chirps)
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp query parameter.
+func parseTimeParam(q url.Values, name string) (sql.NullTime, error) {
+	v := q.Get(name)
+	if v == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
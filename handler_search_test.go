@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSearchChirps(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "chemistry is the study of change"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]string{"body": "yeah science"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+jesse.Token, map[string]string{"body": "chemistry chemistry"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "say my name"}, nil)
+
+	tests := []struct {
+		name     string
+		query    url.Values
+		wantCode int
+		want     string
+	}{
+		{name: "No query", query: url.Values{}, wantCode: 400},
+		{name: "Bad author", query: url.Values{"q": {"chemistry"}, "author_id": {"nope"}}, wantCode: 400},
+		{name: "Bad since", query: url.Values{"q": {"chemistry"}, "since": {"yesterday"}}, wantCode: 400},
+		{name: "Bad offset", query: url.Values{"q": {"chemistry"}, "offset": {"-1"}}, wantCode: 400},
+		{name: "Best match first", query: url.Values{"q": {"chemistry"}}, wantCode: 200, want: "chemistry chemistry,chemistry is the study of change"},
+		{name: "Author", query: url.Values{"q": {"chemistry"}, "author_id": {walt.ID.String()}}, wantCode: 200, want: "chemistry is the study of change"},
+		{name: "Or", query: url.Values{"q": {"science or name"}}, wantCode: 200, want: "yeah science,say my name"},
+		{name: "Excluded", query: url.Values{"q": {"chemistry -study"}}, wantCode: 200, want: "chemistry chemistry"},
+		{name: "Only excluded", query: url.Values{"q": {"-chemistry"}}, wantCode: 200, want: "say my name,yeah science"},
+		{name: "Phrase", query: url.Values{"q": {`"my name"`}}, wantCode: 200, want: "say my name"},
+		{name: "No match", query: url.Values{"q": {"physics"}}, wantCode: 200, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chirps []ChirpResponse
+			var out interface{}
+			if tt.wantCode == 200 {
+				out = &chirps
+			}
+			resp := doJSON(t, srv, "GET", "/api/search/chirps?"+tt.query.Encode(), "", nil, out)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := chirpBodies(chirps); tt.wantCode == 200 && got != tt.want {
+				t.Errorf("results = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var page []ChirpResponse
+	resp := doJSON(t, srv, "GET", "/api/search/chirps?q=chemistry&limit=1", "", nil, &page)
+	next := pageLinks(resp)["next"]
+	if len(page) != 1 || next == "" {
+		t.Fatalf("first page = %d chirps, next %q; want 1 chirp and a next link", len(page), next)
+	}
+	resp = doJSON(t, srv, "GET", next, "", nil, &page)
+	if got := chirpBodies(page); got != "chemistry is the study of change" || pageLinks(resp)["next"] != "" {
+		t.Errorf("second page = %q, next %q; want last chemistry chirp and no next link", got, pageLinks(resp)["next"])
+	}
+}
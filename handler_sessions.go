@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// SessionResponse describes one login. Its ID is the refresh token family,
+// which stays the same as the refresh token is rotated.
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current is set on the session the request's access token belongs to.
+	Current bool `json:"current"`
+}
+
+// handlerListSessions lists the requester's live sessions, most recently
+// used first.
+func (apiCfg *apiConfig) handlerListSessions(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+	userID, sessionID := tok.UserID, tok.SessionID
+
+	rows, err := apiCfg.db.ListSessions(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing sessions: %s", err)
+		respondWithError(w, 500, "Error getting sessions")
+		return
+	}
+
+	sessions := make([]SessionResponse, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, newSessionResponse(row, sessionID))
+	}
+	respondWithJSON(w, 200, sessions)
+}
+
+func newSessionResponse(row database.ListSessionsRow, currentID uuid.UUID) SessionResponse {
+	rt := row.RefreshToken
+	return SessionResponse{
+		ID:         rt.FamilyID,
+		UserAgent:  rt.UserAgent,
+		IPAddress:  rt.IpAddress,
+		SignedInAt: row.SignedInAt,
+		LastUsedAt: rt.LastUsedAt,
+		ExpiresAt:  rt.ExpiresAt,
+		Current:    rt.FamilyID == currentID,
+	}
+}
+
+// handlerRevokeSession signs one of the requester's sessions out: its
+// refresh token stops working. Access tokens already issued for it last
+// until they expire.
+func (apiCfg *apiConfig) handlerRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		respondWithError(w, 400, "sessionID is not a valid id")
+		return
+	}
+
+	n, err := apiCfg.db.RevokeSession(r.Context(), database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		log.Printf("Error revoking session %s: %s", sessionID, err)
+		respondWithError(w, 500, "Error revoking session")
+		return
+	}
+	if n == 0 {
+		respondWithError(w, 404, "Session not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRevokeAllSessions signs the requester out everywhere, including
+// the session making the request, and revokes every access token and API
+// key they hold.
+func (apiCfg *apiConfig) handlerRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := requestPrincipal(r).UserID
+
+	if err := apiCfg.db.RevokeAllSessions(r.Context(), userID); err != nil {
+		log.Printf("Error revoking sessions: %s", err)
+		respondWithError(w, 500, "Error revoking sessions")
+		return
+	}
+	if _, err := apiCfg.tokenVersions.bump(r.Context(), userID); err != nil {
+		log.Printf("Error revoking access tokens: %s", err)
+		respondWithError(w, 500, "Error revoking sessions")
+		return
+	}
+	if err := apiCfg.db.DeleteUserApiKeys(r.Context(), userID); err != nil {
+		log.Printf("Error deleting API keys: %s", err)
+		respondWithError(w, 500, "Error revoking sessions")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSessions(t *testing.T) {
+	_, srv := newTestServer(t)
+	first := createAndLogin(t, srv, "walt@example.com", "04234")
+	var second loginResponse
+	if resp := doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "walt@example.com", "password": "04234"}, &second); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /api/login status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+
+	// Rotating the refresh token keeps the session.
+	code, rotated := refresh(t, srv, first.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("POST /api/refresh status = %d, want %d", code, http.StatusOK)
+	}
+
+	var sessions []SessionResponse
+	if resp := doJSON(t, srv, "GET", "/api/sessions", "Bearer "+rotated.Token, nil, &sessions); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/sessions status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("GET /api/sessions returned %d sessions, want 2", len(sessions))
+	}
+	var current, other SessionResponse
+	for _, s := range sessions {
+		if s.Current {
+			current = s
+		} else {
+			other = s
+		}
+		if s.IPAddress != "127.0.0.1" || s.UserAgent == "" {
+			t.Errorf("session %s ip_address = %q, user_agent = %q", s.ID, s.IPAddress, s.UserAgent)
+		}
+	}
+	if current.ID == uuid.Nil || other.ID == uuid.Nil {
+		t.Fatalf("GET /api/sessions sessions = %+v, want one current and one other", sessions)
+	}
+	if current.SignedInAt.After(current.LastUsedAt) {
+		t.Errorf("current session signed_in_at %v is after last_used_at %v", current.SignedInAt, current.LastUsedAt)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		wantCode      int
+	}{
+		{name: "No token", method: "GET", path: "/api/sessions", wantCode: http.StatusUnauthorized},
+		{name: "Invalid id", method: "DELETE", path: "/api/sessions/nope", authorization: "Bearer " + first.Token, wantCode: http.StatusBadRequest},
+		{name: "Someone else's session", method: "DELETE", path: "/api/sessions/" + other.ID.String(), authorization: "Bearer " + jesse.Token, wantCode: http.StatusNotFound},
+		{name: "Revoke other session", method: "DELETE", path: "/api/sessions/" + other.ID.String(), authorization: "Bearer " + first.Token, wantCode: http.StatusNoContent},
+		{name: "Revoke it again", method: "DELETE", path: "/api/sessions/" + other.ID.String(), authorization: "Bearer " + first.Token, wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doJSON(t, srv, tt.method, tt.path, tt.authorization, nil, nil); resp.StatusCode != tt.wantCode {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	if code, _ := refresh(t, srv, second.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh on revoked session status = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	if resp := doJSON(t, srv, "POST", "/api/sessions/revoke-all", "Bearer "+rotated.Token, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST /api/sessions/revoke-all status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	if code, _ := refresh(t, srv, rotated.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after revoke-all status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	// Logging out everywhere revokes access tokens too.
+	if resp := doJSON(t, srv, "GET", "/api/sessions", "Bearer "+rotated.Token, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /api/sessions after revoke-all status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if code, _ := refresh(t, srv, jesse.RefreshToken); code != http.StatusOK {
+		t.Errorf("POST /api/refresh for another user after revoke-all status = %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// handlerTimeline returns the requester's home timeline: their own chirps and
+// those of everyone they follow, newest first.
+func (apiCfg *apiConfig) handlerTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := requestPrincipal(r).UserID
+
+	page, err := parseFeedPageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	chirps, err := apiCfg.db.GetTimeline(ctx, database.GetTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		Limit:           int32(page.Limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error getting timeline: %s", err)
+		respondWithError(w, 500, "Error getting timeline")
+		return
+	}
+
+	chirps = finishPage(w, r, page, chirps, chirpCursor)
+
+	response_chirps, err := apiCfg.chirpResponses(ctx, chirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error loading timeline likes: %s", err)
+		respondWithError(w, 500, "Error getting timeline")
+		return
+	}
+	respondWithJSON(w, 200, response_chirps)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+// tokenRequest asks for an access token with the space-separated scope,
+// valid for ExpiresIn seconds or accessTokenTTL if it is zero.
+type tokenRequest struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Scope     string    `json:"scope"`
+	ExpiresIn int       `json:"expires_in"`
+}
+
+type TokenResponse struct {
+	Token     string `json:"token"`
+	Scope     string `json:"scope"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// decodeTokenRequest reads a tokenRequest and checks its scope and
+// lifetime. It writes the error response itself and returns ok=false when
+// the request cannot go on.
+func decodeTokenRequest(w http.ResponseWriter, r *http.Request) (req tokenRequest, scopes []string, expiresIn time.Duration, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding request body")
+		return req, nil, 0, false
+	}
+
+	scopes, err := auth.ParseScopes(req.Scope)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return req, nil, 0, false
+	}
+	if len(scopes) == 0 {
+		respondWithError(w, http.StatusBadRequest, "scope is required")
+		return req, nil, 0, false
+	}
+
+	expiresIn = accessTokenTTL
+	if req.ExpiresIn != 0 {
+		expiresIn = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if expiresIn <= 0 || expiresIn > accessTokenTTL {
+		respondWithError(w, http.StatusBadRequest, "expires_in must be between 1 and 3600 seconds")
+		return req, nil, 0, false
+	}
+	return req, scopes, expiresIn, true
+}
+
+// handlerMintToken issues the requester an access token with fewer scopes
+// than their own, to hand to a third-party client. It has no session, so
+// it lasts until it expires or the user's tokens are revoked.
+func (apiCfg *apiConfig) handlerMintToken(w http.ResponseWriter, r *http.Request) {
+	tok := requestPrincipal(r)
+
+	_, scopes, expiresIn, ok := decodeTokenRequest(w, r)
+	if !ok {
+		return
+	}
+	if !auth.HasScopes(tok.Scopes, scopes) {
+		respondWithProblem(w, http.StatusForbidden, "A token cannot grant scopes it does not have")
+		return
+	}
+
+	minted, err := apiCfg.makeAccessToken(r.Context(), auth.AccessToken{UserID: tok.UserID, Scopes: scopes}, expiresIn)
+	if err != nil {
+		log.Printf("Error minting access token: %s", err)
+		respondWithError(w, 500, "Error acquiring JWT")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, TokenResponse{
+		Token:     minted,
+		Scope:     strings.Join(scopes, " "),
+		ExpiresIn: int(expiresIn.Seconds()),
+	})
+}
+
+// handlerAdminMintToken issues an access token for any user with any
+// scopes. It is the only way to get a token with the admin scope.
+func (apiCfg *apiConfig) handlerAdminMintToken(w http.ResponseWriter, r *http.Request) {
+	req, scopes, expiresIn, ok := decodeTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	minted, err := apiCfg.makeAccessToken(r.Context(), auth.AccessToken{UserID: req.UserID, Scopes: scopes}, expiresIn)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error minting access token: %s", err)
+		respondWithError(w, 500, "Error acquiring JWT")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, TokenResponse{
+		Token:     minted,
+		Scope:     strings.Join(scopes, " "),
+		ExpiresIn: int(expiresIn.Seconds()),
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestScopedTokens(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	mint := func(t *testing.T, authorization, path string, body map[string]any) (int, TokenResponse) {
+		t.Helper()
+		var out TokenResponse
+		resp := doJSON(t, srv, "POST", path, authorization, body, &out)
+		return resp.StatusCode, out
+	}
+
+	code, reader := mint(t, "Bearer "+walt.Token, "/api/tokens", map[string]any{"scope": "chirps:read", "expires_in": 600})
+	if code != http.StatusCreated || reader.Scope != "chirps:read" || reader.ExpiresIn != 600 {
+		t.Fatalf("POST /api/tokens = %d %+v, want 201 with chirps:read for 600s", code, reader)
+	}
+
+	mintTests := []struct {
+		name          string
+		authorization string
+		path          string
+		body          map[string]any
+		wantCode      int
+	}{
+		{name: "No token", authorization: "", path: "/api/tokens", body: map[string]any{"scope": "chirps:read"}, wantCode: http.StatusUnauthorized},
+		{name: "No scope", authorization: "Bearer " + walt.Token, path: "/api/tokens", body: map[string]any{"scope": ""}, wantCode: http.StatusBadRequest},
+		{name: "Unknown scope", authorization: "Bearer " + walt.Token, path: "/api/tokens", body: map[string]any{"scope": "chirps:delete"}, wantCode: http.StatusBadRequest},
+		{name: "Too long", authorization: "Bearer " + walt.Token, path: "/api/tokens", body: map[string]any{"scope": "chirps:read", "expires_in": 7200}, wantCode: http.StatusBadRequest},
+		{name: "Escalating to admin", authorization: "Bearer " + walt.Token, path: "/api/tokens", body: map[string]any{"scope": "admin"}, wantCode: http.StatusForbidden},
+		{name: "Reduced token minting", authorization: "Bearer " + reader.Token, path: "/api/tokens", body: map[string]any{"scope": "chirps:read"}, wantCode: http.StatusForbidden},
+		{name: "Admin for an unknown user", authorization: adminAuth, path: "/admin/tokens", body: map[string]any{"user_id": "00000000-0000-0000-0000-000000000000", "scope": "admin"}, wantCode: http.StatusNotFound},
+	}
+	for _, tt := range mintTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := mint(t, tt.authorization, tt.path, tt.body); code != tt.wantCode {
+				t.Errorf("POST %s status = %d, want %d", tt.path, code, tt.wantCode)
+			}
+		})
+	}
+
+	code, writer := mint(t, "Bearer "+walt.Token, "/api/tokens", map[string]any{"scope": "chirps:write"})
+	if code != http.StatusCreated {
+		t.Fatalf("POST /api/tokens status = %d, want %d", code, http.StatusCreated)
+	}
+	code, admin := mint(t, adminAuth, "/admin/tokens", map[string]any{"user_id": walt.ID, "scope": "admin"})
+	if code != http.StatusCreated {
+		t.Fatalf("POST /admin/tokens status = %d, want %d", code, http.StatusCreated)
+	}
+
+	routeTests := []struct {
+		name     string
+		token    string
+		method   string
+		path     string
+		body     any
+		wantCode int
+	}{
+		{name: "Read token reads the timeline", token: reader.Token, method: "GET", path: "/api/timeline", wantCode: http.StatusOK},
+		{name: "Read token reads chirps", token: reader.Token, method: "GET", path: "/api/chirps", wantCode: http.StatusOK},
+		{name: "Read token cannot chirp", token: reader.Token, method: "POST", path: "/api/chirps", body: map[string]string{"body": "hi"}, wantCode: http.StatusForbidden},
+		{name: "Read token cannot change the account", token: reader.Token, method: "PUT", path: "/api/users", body: map[string]string{"email": "w@example.com", "password": "x"}, wantCode: http.StatusForbidden},
+		{name: "Read token cannot mark notifications read", token: reader.Token, method: "POST", path: "/api/notifications/read", wantCode: http.StatusForbidden},
+		{name: "Write token marks notifications read", token: writer.Token, method: "POST", path: "/api/notifications/read", wantCode: http.StatusNoContent},
+		{name: "Admin token cannot read chirps", token: admin.Token, method: "GET", path: "/api/chirps", wantCode: http.StatusForbidden},
+		{name: "Admin token uses the admin API", token: admin.Token, method: "GET", path: "/admin/keys", wantCode: http.StatusOK},
+		{name: "Session token chirps", token: walt.Token, method: "POST", path: "/api/chirps", body: map[string]string{"body": "hi"}, wantCode: http.StatusCreated},
+	}
+	for _, tt := range routeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doJSON(t, srv, tt.method, tt.path, "Bearer "+tt.token, tt.body, nil)
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusForbidden && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Errorf("%s %s has no WWW-Authenticate header", tt.method, tt.path)
+			}
+		})
+	}
+
+	// Minted tokens are revoked with the rest of the user's tokens.
+	doJSON(t, srv, "POST", "/api/sessions/revoke-all", "Bearer "+walt.Token, nil, nil)
+	if resp := doJSON(t, srv, "GET", "/api/timeline", "Bearer "+reader.Token, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /api/timeline after revoke-all status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	// Changing the password replaces the token it was changed with by one
+	// with the same scopes, not every scope the user has.
+	jesse := createAndLogin(t, srv, "jesse@example.com", "abcde")
+	code, account := mint(t, "Bearer "+jesse.Token, "/api/tokens", map[string]any{"scope": "account:write"})
+	if code != http.StatusCreated {
+		t.Fatalf("POST /api/tokens status = %d, want %d", code, http.StatusCreated)
+	}
+	var updated struct {
+		Token string `json:"token"`
+	}
+	patch := map[string]string{"password": "fghij", "current_password": "abcde"}
+	if resp := doJSON(t, srv, "PATCH", "/api/users", "Bearer "+account.Token, patch, &updated); resp.StatusCode != http.StatusOK {
+		t.Fatalf("PATCH /api/users status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	tok, err := apiCfg.keys.ParseAccessToken(updated.Token)
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if got := strings.Join(tok.Scopes, " "); got != "account:write" {
+		t.Errorf("token after a password change has scopes %q, want %q", got, "account:write")
+	}
+}
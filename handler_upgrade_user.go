@@ -15,13 +15,13 @@ func (apiCfg *apiConfig) handlerUpgradeUser(w http.ResponseWriter, r *http.Reque
 	apiKey, err := auth.GetAPIKey(r.Header)
 	if err != nil {
 		log.Printf("No ApiKey found in header: %s", err)
-		respondWithError(w, 401, "No ApiKey found in header")
+		respondWithProblem(w, http.StatusUnauthorized, "No ApiKey found in header")
 		return
 	}
 
 	if apiKey != apiCfg.polka_key {
 		log.Println("apiKey from request is wrong.")
-		respondWithError(w, 401, "apiKey from request is wrong.")
+		respondWithProblem(w, http.StatusUnauthorized, "apiKey from request is wrong.")
 		return
 	}
 
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"mime"
+	"net/http"
+	"net/mail"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// maxDisplayNameLength and maxBioLength are the most characters a display
+// name and a bio may have.
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+// patchField is one member of a JSON Merge Patch: whether it was there,
+// and its value, which is not Valid if it was null.
+type patchField struct {
+	set   bool
+	value sql.NullString
+}
+
+// userPatch is the body of PATCH /api/users.
+type userPatch struct {
+	email           patchField
+	password        patchField
+	currentPassword patchField
+	handle          patchField
+	displayName     patchField
+	bio             patchField
+}
+
+// parseUserPatch reads a merge patch of the user, returning what is wrong
+// with each field that cannot be used.
+func parseUserPatch(patch map[string]json.RawMessage) (userPatch, []fieldError) {
+	var p userPatch
+	var errs []fieldError
+	for _, name := range slices.Sorted(maps.Keys(patch)) {
+		var field *patchField
+		nullable := false
+		maxLength := 0
+		switch name {
+		case "email":
+			field = &p.email
+		case "password":
+			field = &p.password
+		case "current_password":
+			field = &p.currentPassword
+		case "handle":
+			field = &p.handle
+		case "display_name":
+			field, nullable, maxLength = &p.displayName, true, maxDisplayNameLength
+		case "bio":
+			field, nullable, maxLength = &p.bio, true, maxBioLength
+		default:
+			errs = append(errs, fieldError{Field: name, Code: "unknown", Message: "This field cannot be changed"})
+			continue
+		}
+
+		var value *string
+		if err := json.Unmarshal(patch[name], &value); err != nil {
+			errs = append(errs, fieldError{Field: name, Code: "invalid", Message: "Must be a string"})
+			continue
+		}
+		if value == nil && !nullable {
+			errs = append(errs, fieldError{Field: name, Code: "invalid", Message: "Cannot be removed"})
+			continue
+		}
+		*field = patchField{set: true}
+		if value != nil {
+			field.value = sql.NullString{String: *value, Valid: true}
+		}
+		if maxLength > 0 && utf8.RuneCountInString(field.value.String) > maxLength {
+			errs = append(errs, fieldError{Field: name, Code: "too_long", Message: fmt.Sprintf("Must be at most %d characters", maxLength)})
+		}
+	}
+
+	if p.email.set && !validEmail(p.email.value.String) {
+		errs = append(errs, fieldError{Field: "email", Code: "invalid", Message: "Must be an email address"})
+	}
+	if p.handle.set {
+		p.handle.value.String = normalizeHandle(p.handle.value.String)
+		if !validHandle(p.handle.value.String) {
+			errs = append(errs, fieldError{Field: "handle", Code: "invalid", Message: "Handles must be 1 to 15 letters, digits or underscores"})
+		}
+	}
+	if (p.email.set || p.password.set) && !p.currentPassword.set {
+		errs = append(errs, fieldError{Field: "current_password", Code: "required", Message: "Your current password is needed to change your email or password"})
+	}
+	return p, errs
+}
+
+// validEmail reports whether email is a bare address, such as
+// walt@example.com, without a display name or angle brackets.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// handlerUserPatch changes only the parts of the user the request names,
+// as a JSON Merge Patch (RFC 7396): a field that is left out is left as it
+// is, and a profile field set to null is cleared. Changing the email or
+// password needs the current password as well as the access token.
+func (apiCfg *apiConfig) handlerUserPatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tok := requestPrincipal(r)
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+			respondWithError(w, http.StatusUnsupportedMediaType, "Send a JSON Merge Patch as application/merge-patch+json")
+			return
+		}
+	}
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body == nil {
+		respondWithError(w, http.StatusBadRequest, "The request body must be a JSON object")
+		return
+	}
+	patch, errs := parseUserPatch(body)
+	if len(errs) > 0 {
+		respondWithFieldErrors(w, "The update is not valid", errs)
+		return
+	}
+
+	current, err := apiCfg.db.GetUserById(ctx, tok.UserID)
+	if err != nil {
+		log.Printf("Error looking up user: %s", err)
+		respondWithError(w, 500, "Error updating user")
+		return
+	}
+	if patch.currentPassword.set && !apiCfg.checkCurrentPassword(w, r, current, patch.currentPassword.value.String) {
+		return
+	}
+
+	arg := database.PatchUserParams{ID: current.ID}
+	if patch.handle.set && patch.handle.value.String != current.Handle {
+		arg.SetHandle = true
+		arg.Handle = patch.handle.value.String
+	}
+	if patch.displayName.set {
+		arg.SetDisplayName = true
+		arg.DisplayName = patch.displayName.value
+	}
+	if patch.bio.set {
+		arg.SetBio = true
+		arg.Bio = patch.bio.value
+	}
+
+	// As with PUT, a new email waits as the pending_email until it is
+	// verified, and asking for the current one back drops it.
+	newEmail := patch.email.set && patch.email.value.String != current.Email
+	if newEmail {
+		if !apiCfg.checkNewEmail(w, r, current.ID, patch.email.value.String) {
+			return
+		}
+		arg.SetPendingEmail = true
+		arg.PendingEmail = patch.email.value
+	} else if patch.email.set && current.PendingEmail.Valid {
+		arg.SetPendingEmail = true
+		arg.PendingEmail = sql.NullString{}
+	}
+
+	// Only a password that differs from the current one is a change, and
+	// is hashed.
+	newPassword := patch.password.set && patch.password.value.String != patch.currentPassword.value.String
+	if newPassword {
+		if !apiCfg.checkPassword(w, r, patch.password.value.String, current.Email, patch.email.value.String, current.Handle) {
+			return
+		}
+		arg.HashedPassword, err = apiCfg.passwordParams.Hash(patch.password.value.String)
+		if err != nil {
+			log.Printf("Error hashing password: %s", err)
+			respondWithError(w, 500, "Error updating user")
+			return
+		}
+		arg.SetHashedPassword = true
+	}
+
+	updated := current
+	if arg != (database.PatchUserParams{ID: current.ID}) {
+		updated, err = apiCfg.db.PatchUser(ctx, arg)
+		if isUniqueViolation(err, "users_handle_key") {
+			respondWithError(w, http.StatusConflict, "That handle is already taken")
+			return
+		}
+		if err != nil {
+			log.Printf("Error updating user: %s", err)
+			respondWithError(w, 500, "Error updating user")
+			return
+		}
+	}
+	if newEmail {
+		if err := apiCfg.sendVerificationEmail(ctx, current.ID, patch.email.value.String); err != nil {
+			log.Printf("Error sending verification email to user %s: %s", current.ID, err)
+		}
+	}
+
+	token, _ := auth.GetBearerToken(r.Header)
+	if auth.IsAPIKey(token) {
+		token = ""
+	}
+	if newPassword {
+		token, err = apiCfg.signOutOtherSessions(ctx, tok)
+		if err != nil {
+			log.Printf("Error signing out after a password change: %s", err)
+			respondWithError(w, 500, "Error updating user")
+			return
+		}
+	}
+
+	type updatedUser struct {
+		User
+		Token string `json:"token"`
+	}
+
+	respondWithJSON(w, 200, updatedUser{
+		User:  newUser(updated),
+		Token: token,
+	})
+}
+
+// checkCurrentPassword checks the password a user gave to confirm a change
+// to their account, and responds if it is wrong. Wrong guesses count as
+// failed logins, so that a stolen access token cannot be used to find the
+// password. It reports whether the password was right.
+func (apiCfg *apiConfig) checkCurrentPassword(w http.ResponseWriter, r *http.Request, user database.User, password string) bool {
+	wait, err := apiCfg.logins.Check(r.Context(), user.Email, clientIP(r))
+	if err != nil {
+		log.Printf("Error checking login failures: %s", err)
+		respondWithError(w, 500, "Error updating user")
+		return false
+	}
+	if wait > 0 {
+		respondTooManyLogins(w, wait)
+		return false
+	}
+	valid, _, err := apiCfg.passwordParams.Check(password, user.HashedPassword)
+	if err != nil || !valid {
+		if _, err := apiCfg.logins.Failed(r.Context(), user.Email, clientIP(r)); err != nil {
+			log.Printf("Error counting login failure: %s", err)
+		}
+		respondWithProblem(w, http.StatusForbidden, "Incorrect current password")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUserPatch(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	createAndLogin(t, srv, "jesse@example.com", "04234")
+	bearer := "Bearer " + walt.Token
+
+	// Only JSON bodies are taken as merge patches.
+	req, err := http.NewRequest("PATCH", srv.URL+"/api/users", strings.NewReader("bio=hi"))
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	req.Header.Set("Authorization", bearer)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("PATCH /api/users: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("PATCH /api/users with a form = %d, want %d", resp.StatusCode, http.StatusUnsupportedMediaType)
+	}
+
+	type patchResponse struct {
+		User
+		Token  string       `json:"token"`
+		Fields []fieldError `json:"fields"`
+	}
+	tests := []struct {
+		name       string
+		body       map[string]any
+		wantCode   int
+		wantFields []string
+		check      func(t *testing.T, got patchResponse)
+	}{
+		{name: "Profile fields", body: map[string]any{"display_name": "Heisenberg", "bio": "I am the one who knocks"}, wantCode: http.StatusOK, check: func(t *testing.T, got patchResponse) {
+			if got.DisplayName != "Heisenberg" || got.Bio != "I am the one who knocks" || got.Email != "walt@example.com" || got.Handle != "walt" {
+				t.Errorf("PATCH /api/users = %+v, want only the profile changed", got.User)
+			}
+		}},
+		{name: "Remove the bio", body: map[string]any{"bio": nil}, wantCode: http.StatusOK, check: func(t *testing.T, got patchResponse) {
+			if got.Bio != "" || got.DisplayName != "Heisenberg" {
+				t.Errorf("PATCH /api/users = %+v, want no bio and the display name kept", got.User)
+			}
+		}},
+		{name: "Handle", body: map[string]any{"handle": "@Heisenberg"}, wantCode: http.StatusOK, check: func(t *testing.T, got patchResponse) {
+			if got.Handle != "heisenberg" || got.Token != walt.Token {
+				t.Errorf("PATCH /api/users = %+v, want handle heisenberg and the same token", got)
+			}
+		}},
+		{name: "Taken handle", body: map[string]any{"handle": "jesse"}, wantCode: http.StatusConflict},
+		{name: "Bad fields", body: map[string]any{"nickname": "W", "display_name": 5, "handle": nil, "bio": strings.Repeat("x", maxBioLength+1)}, wantCode: http.StatusBadRequest, wantFields: []string{"bio", "display_name", "handle", "nickname"}},
+		{name: "Empty email", body: map[string]any{"email": "", "current_password": "04234"}, wantCode: http.StatusBadRequest, wantFields: []string{"email"}},
+		{name: "Malformed email", body: map[string]any{"email": "Walt <walt@example.com>", "current_password": "04234"}, wantCode: http.StatusBadRequest, wantFields: []string{"email"}},
+		{name: "Email without the current password", body: map[string]any{"email": "walter@example.com"}, wantCode: http.StatusBadRequest, wantFields: []string{"current_password"}},
+		{name: "Wrong current password", body: map[string]any{"password": "bluesky99", "current_password": "wrong"}, wantCode: http.StatusForbidden},
+		{name: "Email", body: map[string]any{"email": "walter@example.com", "current_password": "04234"}, wantCode: http.StatusOK, check: func(t *testing.T, got patchResponse) {
+			if got.Email != "walt@example.com" || got.PendingEmail != "walter@example.com" {
+				t.Errorf("PATCH /api/users = %+v, want walter@example.com pending", got.User)
+			}
+		}},
+		{name: "Password", body: map[string]any{"password": "bluesky99", "current_password": "04234"}, wantCode: http.StatusOK, check: func(t *testing.T, got patchResponse) {
+			if got.Token == "" || got.Token == walt.Token || got.PendingEmail != "walter@example.com" || got.DisplayName != "Heisenberg" {
+				t.Errorf("PATCH /api/users = %+v, want a new token and the rest kept", got)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got patchResponse
+			resp := doJSON(t, srv, "PATCH", "/api/users", bearer, tt.body, &got)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("PATCH /api/users = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			var fields []string
+			for _, f := range got.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("PATCH /api/users field errors = %+v, want %v", got.Fields, tt.wantFields)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+
+	// The password change revoked the old token, and only the new password
+	// logs in.
+	if resp := doJSON(t, srv, "PATCH", "/api/users", bearer, map[string]any{"bio": "Say my name"}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("PATCH /api/users with the old token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	for password, wantCode := range map[string]int{"04234": http.StatusUnauthorized, "bluesky99": http.StatusOK} {
+		if resp := doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "walt@example.com", "password": password}, nil); resp.StatusCode != wantCode {
+			t.Errorf("POST /api/login with %q = %d, want %d", password, resp.StatusCode, wantCode)
+		}
+	}
+}
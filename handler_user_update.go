@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/mrbaker1917/chirpy/internal/auth"
@@ -13,16 +16,8 @@ import (
 
 func (apiCfg *apiConfig) handlerUserUpdate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, 401, "Could not find token in header")
-		return
-	}
-
-	if token == "" {
-		respondWithError(w, 401, "Could not find token in header")
-		return
-	}
+	tok := requestPrincipal(r)
+	userID := tok.UserID
 
 	type reqBody struct {
 		Password string `json:"password"`
@@ -31,30 +26,62 @@ func (apiCfg *apiConfig) handlerUserUpdate(w http.ResponseWriter, r *http.Reques
 
 	decoder := json.NewDecoder(r.Body)
 	reqBdy := reqBody{}
-	err = decoder.Decode(&reqBdy)
+	err := decoder.Decode(&reqBdy)
 	if err != nil {
 		log.Printf("Error decoding user input: %s", err)
 		respondWithError(w, 500, "Error decoding request body")
 		return
 	}
+	if reqBdy.Email != "" && !validEmail(reqBdy.Email) {
+		respondWithFieldErrors(w, "The update is not valid", []fieldError{
+			{Field: "email", Code: "invalid", Message: "Must be an email address"},
+		})
+		return
+	}
 
-	hashed_password, err := auth.HashPassword(reqBdy.Password)
+	current_user, err := apiCfg.db.GetUserById(ctx, userID)
 	if err != nil {
-		log.Printf("Error hashing password: %s", err)
-		respondWithError(w, 500, "Error hasing password")
+		log.Printf("Error looking up user: %s", err)
+		respondWithError(w, 500, "Error updating user")
 		return
 	}
-
-	userID, err := auth.ValidateJWT(token, apiCfg.secret)
+	samePassword, err := auth.CheckPasswordHash(reqBdy.Password, current_user.HashedPassword)
 	if err != nil {
-		log.Printf("Error validating access token %s", err)
-		respondWithError(w, 401, "Error validating access token")
+		log.Printf("Error checking password: %s", err)
+		respondWithError(w, 500, "Error updating user")
 		return
 	}
 
+	hashed_password := current_user.HashedPassword
+	if !samePassword {
+		if !apiCfg.checkPassword(w, r, reqBdy.Password, current_user.Email, reqBdy.Email, current_user.Handle) {
+			return
+		}
+		hashed_password, err = apiCfg.passwordParams.Hash(reqBdy.Password)
+		if err != nil {
+			log.Printf("Error hashing password: %s", err)
+			respondWithError(w, 500, "Error hasing password")
+			return
+		}
+	}
+
+	// A new email only replaces the one the user logs in with once it is
+	// verified. Until then it waits as their pending_email, and asking for
+	// the current email back drops it.
+	pending := current_user.PendingEmail
+	newEmail := reqBdy.Email != "" && reqBdy.Email != current_user.Email
+	if newEmail {
+		if !apiCfg.checkNewEmail(w, r, userID, reqBdy.Email) {
+			return
+		}
+		pending = sql.NullString{String: reqBdy.Email, Valid: true}
+	} else if reqBdy.Email == current_user.Email {
+		pending = sql.NullString{}
+	}
+
 	updated_user, err := apiCfg.db.UpdateUser(ctx, database.UpdateUserParams{
 		ID:             userID,
-		Email:          reqBdy.Email,
+		Email:          current_user.Email,
 		HashedPassword: hashed_password,
 	})
 
@@ -64,22 +91,93 @@ func (apiCfg *apiConfig) handlerUserUpdate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if pending != current_user.PendingEmail {
+		err := apiCfg.db.SetPendingEmail(ctx, database.SetPendingEmailParams{
+			ID:           userID,
+			PendingEmail: pending,
+		})
+		if err != nil {
+			log.Printf("Error updating user: %s", err)
+			respondWithError(w, 500, "Error updating user")
+			return
+		}
+		updated_user.PendingEmail = pending
+	}
+	if newEmail {
+		if err := apiCfg.sendVerificationEmail(ctx, userID, reqBdy.Email); err != nil {
+			log.Printf("Error sending verification email to user %s: %s", userID, err)
+		}
+	}
+
+	// A new password signs out every other session and revokes every
+	// access token issued before it, including this one, which the
+	// response replaces. Otherwise the access token the request was made
+	// with stays good and is handed back; an API key is not.
+	token, _ := auth.GetBearerToken(r.Header)
+	if auth.IsAPIKey(token) {
+		token = ""
+	}
+	if !samePassword {
+		token, err = apiCfg.signOutOtherSessions(ctx, tok)
+		if err != nil {
+			log.Printf("Error signing out after a password change: %s", err)
+			respondWithError(w, 500, "Error updating user")
+			return
+		}
+	}
+
 	type updatedUser struct {
-		ID          uuid.UUID `json:"id"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
-		Email       string    `json:"email"`
-		Token       string    `json:"token"`
-		IsChirpyRed bool      `json:"is_chirpy_red"`
+		User
+		Token string `json:"token"`
 	}
 
 	respondWithJSON(w, 200, updatedUser{
-		ID:          updated_user.ID,
-		CreatedAt:   updated_user.CreatedAt,
-		UpdatedAt:   updated_user.UpdatedAt,
-		Email:       updated_user.Email,
-		Token:       token,
-		IsChirpyRed: updated_user.IsChirpyRed,
+		User:  newUser(updated_user),
+		Token: token,
 	})
+}
+
+// checkNewEmail checks that userID can change their email to email, and
+// responds saying why not if they cannot. It reports whether they can.
+func (apiCfg *apiConfig) checkNewEmail(w http.ResponseWriter, r *http.Request, userID uuid.UUID, email string) bool {
+	if apiCfg.mailer == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Changing your email is not available")
+		return false
+	}
+	other, err := apiCfg.db.GetUserByEmail(r.Context(), email)
+	if err == nil && other.ID != userID {
+		respondWithError(w, http.StatusConflict, "That email is already in use")
+		return false
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error looking up user: %s", err)
+		respondWithError(w, 500, "Error updating user")
+		return false
+	}
+	return true
+}
 
+// signOutOtherSessions follows a password change by signing out every
+// session but tok's, deleting the user's API keys and revoking every access
+// token issued before it, including tok. It returns a new access token with
+// the same scopes to replace tok.
+func (apiCfg *apiConfig) signOutOtherSessions(ctx context.Context, tok auth.AccessToken) (string, error) {
+	err := apiCfg.db.RevokeOtherSessions(ctx, database.RevokeOtherSessionsParams{
+		UserID:   tok.UserID,
+		FamilyID: tok.SessionID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("revoking sessions: %w", err)
+	}
+	if err := apiCfg.db.DeleteUserApiKeys(ctx, tok.UserID); err != nil {
+		return "", fmt.Errorf("deleting API keys: %w", err)
+	}
+	if _, err := apiCfg.tokenVersions.bump(ctx, tok.UserID); err != nil {
+		return "", fmt.Errorf("revoking access tokens: %w", err)
+	}
+	return apiCfg.makeAccessToken(ctx, auth.AccessToken{
+		UserID:    tok.UserID,
+		SessionID: tok.SessionID,
+		Scopes:    tok.Scopes,
+	}, accessTokenTTL)
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+const (
+	maxHandleLength = 15
+	// maxHandleAttempts is how many random suffixes are tried when the
+	// handle suggested for a new user is taken.
+	maxHandleAttempts = 5
+)
+
+// normalizeHandle returns handle in the form it is stored in: lowercased
+// and without a leading @.
+func normalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// validHandle reports whether a normalized handle is 1 to 15 lowercase
+// letters, digits and underscores.
+func validHandle(handle string) bool {
+	if handle == "" || len(handle) > maxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHandleRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+// handleFromEmail suggests a handle for users who sign up without choosing
+// one, made from the local part of their email.
+func handleFromEmail(email string) string {
+	local, _, _ := strings.Cut(email, "@")
+	var b strings.Builder
+	for _, r := range strings.ToLower(local) {
+		if isHandleRune(r) && b.Len() < maxHandleLength {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "user"
+	}
+	return b.String()
+}
+
+// withHandleSuffix makes another candidate when a suggested handle is taken
+// by replacing its end with random digits.
+func withHandleSuffix(handle string) string {
+	suffix := fmt.Sprintf("%04d", rand.IntN(10000))
+	return handle[:min(len(handle), maxHandleLength-len(suffix))] + suffix
+}
+
+type mention struct {
+	Handle string
+	// Start and End are the offsets of "@handle" in the body, counted in
+	// Unicode code points, with End exclusive.
+	Start, End int
+}
+
+// extractMentions returns the @handles in a chirp body, normalized. An @ only
+// starts a mention when it does not follow a handle character, so email
+// addresses are not mentions, and runs too long to be handles are skipped.
+func extractMentions(body string) []mention {
+	var mentions []mention
+
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && (isHandleRune(runes[i-1]) || runes[i-1] == '@')) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isHandleRune(runes[j]) {
+			j++
+		}
+		if handle := normalizeHandle(string(runes[i+1 : j])); validHandle(handle) {
+			mentions = append(mentions, mention{Handle: handle, Start: i, End: j})
+		}
+		i = j - 1
+	}
+	return mentions
+}
+
+// saveMentions stores the mentions in a new chirp that name existing users
+// and returns those users' ids, each once.
+func (apiCfg *apiConfig) saveMentions(ctx context.Context, chirp database.Chirp) ([]uuid.UUID, error) {
+	mentions := extractMentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	handles := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		handles = append(handles, m.Handle)
+	}
+	users, err := apiCfg.db.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+	byHandle := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		byHandle[user.Handle] = user.ID
+	}
+
+	arg := database.AddChirpMentionsParams{ChirpID: chirp.ID}
+	var mentioned []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, m := range mentions {
+		userID, ok := byHandle[m.Handle]
+		if !ok {
+			continue
+		}
+		arg.UserIds = append(arg.UserIds, userID)
+		arg.StartOffsets = append(arg.StartOffsets, int32(m.Start))
+		arg.EndOffsets = append(arg.EndOffsets, int32(m.End))
+		if !seen[userID] {
+			seen[userID] = true
+			mentioned = append(mentioned, userID)
+		}
+	}
+	if len(mentioned) == 0 {
+		return nil, nil
+	}
+
+	if err := apiCfg.db.AddChirpMentions(ctx, arg); err != nil {
+		return nil, err
+	}
+	return mentioned, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "None", body: "no mentions here", want: "[]"},
+		{name: "One", body: "hi @Walt!", want: "[{walt 3 8}]"},
+		{name: "Offsets count code points", body: "héllo @jesse", want: "[{jesse 6 12}]"},
+		{name: "Repeated", body: "@a @a", want: "[{a 0 2} {a 3 5}]"},
+		{name: "Email address", body: "mail walt@example.com", want: "[]"},
+		{name: "Too long", body: "@abcdefghijklmnopq", want: "[]"},
+		{name: "Bare at", body: "meet @ noon", want: "[]"},
+		{name: "Double at", body: "@@walt", want: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(extractMentions(tt.body)); got != tt.want {
+				t.Errorf("extractMentions(%q) = %s, want %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFromEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "walt@example.com", want: "walt"},
+		{email: "Walter.White+chem@example.com", want: "walterwhitechem"},
+		{email: "averyveryverylongname@example.com", want: "averyveryverylo"},
+		{email: "...@example.com", want: "user"},
+	}
+
+	for _, tt := range tests {
+		got := handleFromEmail(tt.email)
+		if got != tt.want {
+			t.Errorf("handleFromEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+		if suffixed := withHandleSuffix(got); !validHandle(suffixed) {
+			t.Errorf("withHandleSuffix(%q) = %q, not a valid handle", got, suffixed)
+		}
+	}
+}
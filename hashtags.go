@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+const maxHashtagLength = 50
+
+// extractHashtags returns the distinct #hashtags in a chirp body, lowercased,
+// in the order they first appear. A tag is a run of letters, digits and
+// underscores with at least one letter, and only counts when the # does not
+// follow another word character, so "a#b" and "#123" are not tags.
+func extractHashtags(body string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && (isHashtagRune(runes[i-1]) || runes[i-1] == '#')) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isHashtagRune(runes[j]) {
+			j++
+		}
+		tag := strings.ToLower(string(runes[i+1 : j]))
+		if validHashtag(tag) && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		i = j - 1
+	}
+	return tags
+}
+
+func isHashtagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// validHashtag reports whether tag, without its #, is one extractHashtags
+// could have produced.
+func validHashtag(tag string) bool {
+	if tag == "" || len([]rune(tag)) > maxHashtagLength || tag != strings.ToLower(tag) {
+		return false
+	}
+	hasLetter := false
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
+
+const (
+	// trendingWindow is how far back trending tags look.
+	trendingWindow = 24 * time.Hour
+	// trendingHalfLife is how long it takes a use of a tag to count for
+	// half as much.
+	trendingHalfLife = 6 * time.Hour
+	// trendingMaxAge is how stale the cached list may get before a request
+	// refreshes it itself. main refreshes it in the background more often
+	// than this, so requests normally never wait on the query.
+	trendingMaxAge          = 5 * time.Minute
+	trendingRefreshInterval = time.Minute
+	trendingCacheSize       = maxPageLimit
+)
+
+type TrendingHashtag struct {
+	Tag        string  `json:"tag"`
+	ChirpCount int64   `json:"chirp_count"`
+	Score      float64 `json:"score"`
+}
+
+// trendingHashtags caches the top hashtags, since scoring them means
+// aggregating every tag used in the window.
+type trendingHashtags struct {
+	db database.Store
+
+	mu        sync.Mutex
+	tags      []TrendingHashtag
+	updatedAt time.Time
+}
+
+func newTrendingHashtags(db database.Store) *trendingHashtags {
+	return &trendingHashtags{db: db}
+}
+
+// get returns the cached top tags, refreshing them first if they have never
+// been loaded or are older than trendingMaxAge.
+func (t *trendingHashtags) get(ctx context.Context) ([]TrendingHashtag, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if time.Since(t.updatedAt) > trendingMaxAge {
+		if err := t.refreshLocked(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return t.tags, nil
+}
+
+func (t *trendingHashtags) refresh(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.refreshLocked(ctx)
+}
+
+// refreshLocked recomputes the top tags. The caller must hold t.mu.
+func (t *trendingHashtags) refreshLocked(ctx context.Context) error {
+	rows, err := t.db.GetTrendingHashtags(ctx, database.GetTrendingHashtagsParams{
+		HalfLifeSeconds: trendingHalfLife.Seconds(),
+		Since:           time.Now().UTC().Add(-trendingWindow),
+		Limit:           trendingCacheSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	tags := make([]TrendingHashtag, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, TrendingHashtag{
+			Tag:        row.Tag,
+			ChirpCount: row.ChirpCount,
+			Score:      row.Score,
+		})
+	}
+	t.tags = tags
+	t.updatedAt = time.Now()
+	return nil
+}
+
+// run refreshes the cache every interval until ctx is done.
+func (t *trendingHashtags) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := t.refresh(ctx); err != nil {
+			log.Printf("Error refreshing trending hashtags: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "None", body: "no tags here", want: ""},
+		{name: "One", body: "learning #golang today", want: "golang"},
+		{name: "Lowercased and deduplicated", body: "#Go #go #GO", want: "go"},
+		{name: "Punctuation ends a tag", body: "#boot.dev, #chirpy!", want: "boot,chirpy"},
+		{name: "Underscores and digits", body: "#web_3 #2025goals", want: "web_3,2025goals"},
+		{name: "Digits only", body: "issue #123", want: ""},
+		{name: "Inside a word", body: "a#b", want: ""},
+		{name: "Double hash", body: "##tag", want: ""},
+		{name: "Unicode", body: "#café time", want: "café"},
+		{name: "Bare hash", body: "# nothing", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(extractHashtags(tt.body), ","); got != tt.want {
+				t.Errorf("extractHashtags(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
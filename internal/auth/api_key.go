@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// APIKeyPrefix starts every user API key, so that keys can be told apart
+// from JWTs and spotted by secret scanners.
+const APIKeyPrefix = "chirpy_"
+
+// apiKeyVisibleLength is how much of a key is kept in the clear to name it.
+const apiKeyVisibleLength = len(APIKeyPrefix) + 8
+
+// MakeAPIKey returns a new user API key and the start of it that may be
+// shown once the key itself is gone.
+func MakeAPIKey() (key, prefix string) {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	key = APIKeyPrefix + hex.EncodeToString(secret)
+	return key, key[:apiKeyVisibleLength]
+}
+
+// IsAPIKey reports whether credential looks like a key from MakeAPIKey
+// rather than a JWT.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// HashAPIKey returns the hex SHA-256 of a user API key, which is what gets
+// stored. Like refresh tokens, the keys are 256 random bits, so a plain
+// hash is enough.
+func HashAPIKey(key string) string {
+	return HashRefreshToken(key)
+}
+
+// GetCredential returns the access token in "Authorization: Bearer <token>"
+// or the user API key in "Authorization: ApiKey <key>".
+func GetCredential(headers http.Header) (string, error) {
+	if token, err := GetBearerToken(headers); err == nil {
+		return token, nil
+	}
+	key, err := GetAPIKey(headers)
+	if err != nil {
+		return "", errors.New("no bearer token or ApiKey found")
+	}
+	return key, nil
+}
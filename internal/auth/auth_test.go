@@ -1,10 +1,18 @@
 package auth
 
 import (
+	"context"
+	"crypto/sha1"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -72,6 +80,55 @@ func TestCheckPasswordHash(t *testing.T) {
 	}
 }
 
+func TestPasswordParams(t *testing.T) {
+	cheap := PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	costlier := cheap
+	costlier.Iterations = 2
+
+	hash, err := cheap.Hash("correctPassword123!")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	tests := []struct {
+		name       string
+		params     PasswordParams
+		password   string
+		wantMatch  bool
+		wantRehash bool
+	}{
+		{name: "Same parameters", params: cheap, password: "correctPassword123!", wantMatch: true, wantRehash: false},
+		{name: "Newer parameters", params: costlier, password: "correctPassword123!", wantMatch: true, wantRehash: true},
+		{name: "Wrong password", params: costlier, password: "wrongPassword", wantMatch: false, wantRehash: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := tt.params.Check(tt.password, hash)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if match != tt.wantMatch || rehash != tt.wantRehash {
+				t.Errorf("Check() = %v, %v, want %v, %v", match, rehash, tt.wantMatch, tt.wantRehash)
+			}
+		})
+	}
+
+	invalid := []PasswordParams{
+		{Memory: 64, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 0, SaltLength: 16, KeyLength: 32},
+		{Memory: 16, Iterations: 1, Parallelism: 4, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 4, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 8},
+	}
+	for _, p := range invalid {
+		if _, err := p.Hash("correctPassword123!"); err == nil {
+			t.Errorf("Hash() with %+v succeeded, want an error", p)
+		}
+	}
+	if err := DefaultPasswordParams.Validate(); err != nil {
+		t.Errorf("DefaultPasswordParams.Validate() = %v", err)
+	}
+}
+
 func TestValidateJWT(t *testing.T) {
 	userID := uuid.New()
 	validToken, _ := MakeJWT(userID, "secret", time.Hour)
@@ -187,3 +244,318 @@ func TestGetBearerToken(t *testing.T) {
 	}
 
 }
+
+func TestHashRefreshToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{
+			name:  "empty token",
+			token: "",
+			want:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:  "hex token",
+			token: "abc",
+			want:  "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashRefreshToken(tt.token); got != tt.want {
+				t.Errorf("HashRefreshToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSessionJWT(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	sessionToken, _ := MakeSessionJWT(userID, sessionID, "secret", time.Hour)
+	plainToken, _ := MakeJWT(userID, "secret", time.Hour)
+
+	tests := []struct {
+		name          string
+		tokenString   string
+		wantSessionID uuid.UUID
+		wantErr       bool
+	}{
+		{
+			name:          "Session token",
+			tokenString:   sessionToken,
+			wantSessionID: sessionID,
+			wantErr:       false,
+		},
+		{
+			name:          "Token without a session",
+			tokenString:   plainToken,
+			wantSessionID: uuid.Nil,
+			wantErr:       false,
+		},
+		{
+			name:          "Invalid string",
+			tokenString:   "not.a.jwt",
+			wantSessionID: uuid.Nil,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, gotSessionID, err := ValidateSessionJWT(tt.tokenString, "secret")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSessionJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && gotUserID != userID {
+				t.Errorf("ValidateSessionJWT() userID = %v, want %v", gotUserID, userID)
+			}
+			if gotSessionID != tt.wantSessionID {
+				t.Errorf("ValidateSessionJWT() sessionID = %v, want %v", gotSessionID, tt.wantSessionID)
+			}
+		})
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Empty", scope: "", want: nil, wantErr: false},
+		{name: "One scope", scope: "chirps:read", want: []string{ScopeChirpsRead}, wantErr: false},
+		{name: "Duplicates and extra spaces", scope: " chirps:read  admin chirps:read", want: []string{ScopeChirpsRead, ScopeAdmin}, wantErr: false},
+		{name: "Unknown scope", scope: "chirps:read chirps:delete", want: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScopes(tt.scope)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !HasScopes(got, tt.want) || len(got) != len(tt.want) {
+				t.Errorf("ParseScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopesRoundTrip(t *testing.T) {
+	scoped := newClaims(AccessToken{UserID: uuid.New(), Scopes: []string{ScopeChirpsRead}}, time.Hour)
+	if scoped.Scope != "chirps:read" {
+		t.Errorf("newClaims() scope = %q, want %q", scoped.Scope, "chirps:read")
+	}
+
+	// Tokens from before scopes existed can do what a user can.
+	legacy, _ := MakeJWT(uuid.New(), "secret", time.Hour)
+	tok, err := parseJWT(legacy, func(*jwt.Token) (interface{}, error) { return []byte("secret"), nil })
+	if err != nil {
+		t.Fatalf("parseJWT() error = %v", err)
+	}
+	if !HasScopes(tok.Scopes, UserScopes) || tok.HasScope(ScopeAdmin) {
+		t.Errorf("parseJWT() scopes of a token without a scope claim = %v, want %v", tok.Scopes, UserScopes)
+	}
+}
+
+func TestGetCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{name: "Bearer token", header: "Bearer abc.def.ghi", want: "abc.def.ghi", wantErr: false},
+		{name: "API key", header: "ApiKey chirpy_123", want: "chirpy_123", wantErr: false},
+		{name: "Other scheme", header: "Basic dXNlcg==", want: "", wantErr: true},
+		{name: "No header", header: "", want: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("Authorization", tt.header)
+			}
+			got, err := GetCredential(headers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCredential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetCredential() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	key, prefix := MakeAPIKey()
+	if !IsAPIKey(key) || len(prefix) != apiKeyVisibleLength || key[:len(prefix)] != prefix {
+		t.Errorf("MakeAPIKey() = %q, %q, want a chirpy_ key starting with its prefix", key, prefix)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// The SHA-1 test vectors from RFC 6238 appendix B, cut to six digits.
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // "12345678901234567890"
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "59", unix: 59, want: "287082"},
+		{name: "1111111109", unix: 1111111109, want: "081804"},
+		{name: "1234567890", unix: 1234567890, want: "005924"},
+		{name: "20000000000", unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TOTPCode(secret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("TOTPCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TOTPCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	now := time.Unix(1234567890, 0)
+	validateTests := []struct {
+		name   string
+		code   string
+		at     time.Time
+		wantOK bool
+	}{
+		{name: "Current code", code: "005924", at: now, wantOK: true},
+		{name: "Code from the last period", code: "005924", at: now.Add(TOTPPeriod), wantOK: true},
+		{name: "Code from two periods ago", code: "005924", at: now.Add(2 * TOTPPeriod), wantOK: false},
+		{name: "Wrong code", code: "123456", at: now, wantOK: false},
+		{name: "Too short", code: "5924", at: now, wantOK: false},
+	}
+	for _, tt := range validateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(secret, tt.code, tt.at)
+			if ok != tt.wantOK {
+				t.Errorf("ValidateTOTP() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != TOTPStep(now) {
+				t.Errorf("ValidateTOTP() step = %d, want %d", step, TOTPStep(now))
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes := MakeRecoveryCodes(10)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 19 || seen[code] {
+			t.Errorf("MakeRecoveryCodes() gave %q, want unique xxxx-xxxx-xxxx-xxxx codes", code)
+		}
+		seen[code] = true
+	}
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))) {
+		t.Errorf("HashRecoveryCode() depends on case, spaces or dashes")
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	tests := []struct {
+		password   string
+		userInputs []string
+		wantStrong bool
+	}{
+		{password: "password", wantStrong: false},
+		{password: "P@ssw0rd!", wantStrong: false},
+		{password: "drowssap", wantStrong: false},
+		{password: "qwertyuiop", wantStrong: false},
+		{password: "abcdefgh12345678", wantStrong: false},
+		{password: "zzzzzzzzzzzzzzzz", wantStrong: false},
+		{password: "Sunshine1987", wantStrong: false},
+		{password: "walterwhite2008", userInputs: []string{"walter.white@example.com", "heisenberg"}, wantStrong: false},
+		{password: "walterwhite2008", wantStrong: true},
+		{password: "correct horse battery staple", wantStrong: true},
+		{password: "k8#Vq2!pLz", wantStrong: true},
+	}
+	for _, tt := range tests {
+		got := PasswordEntropy(tt.password, tt.userInputs...)
+		if (got >= DefaultPasswordPolicy.MinEntropy) != tt.wantStrong {
+			t.Errorf("PasswordEntropy(%q, %q) = %.1f bits, want strong %v", tt.password, tt.userInputs, got, tt.wantStrong)
+		}
+	}
+	if got := PasswordEntropy(""); got != 0 {
+		t.Errorf("PasswordEntropy(\"\") = %v, want 0", got)
+	}
+}
+
+// writeBreachedFile writes the SHA-1 hashes of passwords to a BreachedFile
+// the way the Pwned Passwords download does.
+func writeBreachedFile(t *testing.T, passwords ...string) BreachedFile {
+	t.Helper()
+	var lines []string
+	for i, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%X:%d\r\n", sum, i+1))
+	}
+	slices.Sort(lines)
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return BreachedFile{Path: path}
+}
+
+func TestBreachedFile(t *testing.T) {
+	var breached []string
+	for i := range 500 {
+		breached = append(breached, fmt.Sprintf("leaked%d", i))
+	}
+	list := writeBreachedFile(t, breached...)
+
+	// The hashes are in random order, so these are spread through the file.
+	tests := map[string]bool{"password": false, "leaked500": false, "": false}
+	for _, password := range breached[:20] {
+		tests[password] = true
+	}
+	for password, want := range tests {
+		got, err := IsBreachedPassword(context.Background(), list, password)
+		if err != nil {
+			t.Fatalf("IsBreachedPassword(%q) error = %v", password, err)
+		}
+		if got != want {
+			t.Errorf("IsBreachedPassword(%q) = %v, want %v", password, got, want)
+		}
+	}
+	if _, err := IsBreachedPassword(context.Background(), BreachedFile{Path: filepath.Join(t.TempDir(), "missing")}, "password"); err == nil {
+		t.Errorf("IsBreachedPassword() with a missing file succeeded, want an error")
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := DefaultPasswordPolicy
+	policy.Breached = writeBreachedFile(t, "k8#Vq2!pLz", "short")
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{password: "n0t-in-any-l1st!", want: nil},
+		{password: "short", want: []string{PasswordTooShort, PasswordBreached}},
+		{password: "walt1234", want: []string{PasswordTooWeak}},
+		{password: "k8#Vq2!pLz", want: []string{PasswordBreached}},
+	}
+	for _, tt := range tests {
+		problems, err := policy.Check(context.Background(), tt.password, "walt@example.com")
+		if err != nil {
+			t.Fatalf("Check(%q) error = %v", tt.password, err)
+		}
+		var got []string
+		for _, p := range problems {
+			got = append(got, p.Code)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
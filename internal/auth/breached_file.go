@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedFile is a BreachedPasswords list kept in a file of SHA-1 hashes
+// in hex, one to a line in order, each optionally followed by a colon and
+// a count: the Pwned Passwords download ordered by hash, for one. The file
+// is searched where it is rather than read in, so it can be far bigger than
+// memory.
+type BreachedFile struct {
+	Path string
+}
+
+var _ BreachedPasswords = BreachedFile{}
+
+func (f BreachedFile) Range(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	prefix = strings.ToUpper(prefix)
+
+	// Find the first line whose hash is not before prefix. The lines that
+	// start at or after an offset only get later as the offset grows, so
+	// the offset can be found by bisection.
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		_, hash, err := hashAtOrAfter(file, size, mid)
+		if err != nil {
+			return nil, err
+		}
+		if hash == "" || hash >= prefix {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	start, _, err := hashAtOrAfter(file, size, lo)
+	if err != nil {
+		return nil, err
+	}
+
+	var suffixes []string
+	lines := bufio.NewScanner(io.NewSectionReader(file, start, size-start))
+	for lines.Scan() {
+		hash := breachedHash(lines.Text())
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes = append(suffixes, hash[len(prefix):])
+	}
+	return suffixes, lines.Err()
+}
+
+// hashAtOrAfter returns where the first line starting at or after off
+// starts, and its hash, which is empty if there is no such line.
+func hashAtOrAfter(file *os.File, size, off int64) (int64, string, error) {
+	start := off
+	if off > 0 {
+		// off is a line start if the byte before it ends a line.
+		buf := make([]byte, 128)
+		for start = off - 1; ; start += int64(len(buf)) {
+			n, err := file.ReadAt(buf, start)
+			if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+				start += int64(i) + 1
+				break
+			}
+			if errors.Is(err, io.EOF) {
+				return size, "", nil
+			}
+			if err != nil {
+				return 0, "", err
+			}
+		}
+	}
+	line, err := bufio.NewReader(io.NewSectionReader(file, start, size-start)).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", err
+	}
+	return start, breachedHash(line), nil
+}
+
+// breachedHash is the hash on a line of a BreachedFile, in upper case.
+func breachedHash(line string) string {
+	hash, _, _ := strings.Cut(line, ":")
+	return strings.ToUpper(strings.TrimSpace(hash))
+}
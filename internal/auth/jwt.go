@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"cmp"
 	"errors"
 	"log"
 	"net/http"
@@ -11,14 +12,46 @@ import (
 	"github.com/google/uuid"
 )
 
-func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
-	now := time.Now()
-	newJWT := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(now),
-		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
-		Subject:   userID.String(),
-	})
+// Claims are the claims in a Chirpy access token. SessionID is the
+// session (refresh token family) the token was issued for, if any, and
+// TokenVersion the user's token version at the time. Scope lists what the
+// token may be used for, separated by spaces.
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID    string `json:"sid,omitempty"`
+	TokenVersion int32  `json:"ver,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// AccessToken is what an access token says about its bearer.
+type AccessToken struct {
+	// ID is the token's jti. MakeAccessToken fills it in.
+	ID     string
+	UserID uuid.UUID
+	// SessionID is the session the token was issued for, or uuid.Nil.
+	SessionID uuid.UUID
+	// Version is the user's token version when the token was issued. Bumping
+	// the user's version revokes every token issued before.
+	Version int32
+	// Scopes are what the token may be used for. A token made with none
+	// is read back with UserScopes.
+	Scopes []string
+}
+
+// HasScope reports whether the token may be used for scope.
+func (t AccessToken) HasScope(scope string) bool {
+	return HasScope(t.Scopes, scope)
+}
+
+func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return MakeSessionJWT(userID, uuid.Nil, tokenSecret, expiresIn)
+}
+
+// MakeSessionJWT is MakeJWT for a token that belongs to a session, so that
+// the session can be recognised when the token is presented.
+func MakeSessionJWT(userID, sessionID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	claims := newClaims(AccessToken{UserID: userID, SessionID: sessionID}, expiresIn)
+	newJWT := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	ss, err := newJWT.SignedString([]byte(tokenSecret))
 	if err != nil {
@@ -27,39 +60,91 @@ func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
 	return ss, nil
 }
 
+func newClaims(tok AccessToken, expiresIn time.Duration) Claims {
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        cmp.Or(tok.ID, uuid.NewString()),
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   tok.UserID.String(),
+		},
+		TokenVersion: tok.Version,
+		Scope:        strings.Join(tok.Scopes, " "),
+	}
+	if tok.SessionID != uuid.Nil {
+		claims.SessionID = tok.SessionID.String()
+	}
+	return claims
+}
+
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&jwt.RegisteredClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(tokenSecret), nil
-		})
+	id, _, err := ValidateSessionJWT(tokenString, tokenSecret)
+	return id, err
+}
+
+// ValidateSessionJWT is ValidateJWT that also returns the session the token
+// was issued for, or uuid.Nil if it has none.
+func ValidateSessionJWT(tokenString, tokenSecret string) (uuid.UUID, uuid.UUID, error) {
+	tok, err := parseJWT(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(tokenSecret), nil
+	})
+	return tok.UserID, tok.SessionID, err
+}
+
+// parseJWT checks a token with the key keyFunc picks for it and returns
+// what it says.
+func parseJWT(tokenString string, keyFunc jwt.Keyfunc) (AccessToken, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyFunc)
 
 	if err != nil {
 		log.Printf("JWT parsing error: %v", err)
-		return uuid.UUID{}, err
+		return AccessToken{}, err
 	}
 
 	if !token.Valid {
-		return uuid.UUID{}, errors.New("invalid token")
+		return AccessToken{}, errors.New("invalid token")
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return uuid.UUID{}, errors.New("invalid token")
+		return AccessToken{}, errors.New("invalid token")
 	}
 
 	idStr := claims.Subject
 
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		return uuid.UUID{}, errors.New("unable to parse")
+		return AccessToken{}, errors.New("unable to parse")
+	}
+
+	var sessionID uuid.UUID
+	if claims.SessionID != "" {
+		sessionID, err = uuid.Parse(claims.SessionID)
+		if err != nil {
+			return AccessToken{}, errors.New("unable to parse")
+		}
+	}
+
+	scopes := UserScopes
+	if claims.Scope != "" {
+		scopes, err = ParseScopes(claims.Scope)
+		if err != nil {
+			return AccessToken{}, err
+		}
 	}
 
-	return id, nil
+	return AccessToken{
+		ID:        claims.ID,
+		UserID:    id,
+		SessionID: sessionID,
+		Version:   claims.TokenVersion,
+		Scopes:    scopes,
+	}, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// sealedKeyVersion starts every key sealed by a KeyCipher. PKCS #8 DER
+// always starts with 0x30, so keys stored before they were encrypted can
+// be told apart.
+const sealedKeyVersion = 1
+
+var (
+	// ErrKeyNotSealed is returned by KeyCipher.Open for a key that was
+	// stored unencrypted.
+	ErrKeyNotSealed = errors.New("signing key is not encrypted")
+	// ErrKeySealed is returned by KeyCipher.Open when the key was sealed
+	// with a different cipher key or for a different key ID, or has been
+	// changed.
+	ErrKeySealed = errors.New("signing key cannot be decrypted")
+)
+
+// KeyCipher encrypts signing keys with AES-256-GCM before they are stored,
+// so that a copy of the database is not enough to sign tokens.
+type KeyCipher struct {
+	aead cipher.AEAD
+}
+
+// NewKeyCipher returns a KeyCipher that uses key, which must be 32 bytes.
+func NewKeyCipher(key []byte) (*KeyCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key cipher needs a 32 byte key, got %d bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyCipher{aead: aead}, nil
+}
+
+// Seal encrypts der, a key encoded by MarshalSigningKey, for the signing key
+// named id. The result only opens for the same id, so that a sealed key
+// cannot be moved to another row.
+func (c *KeyCipher) Seal(id string, der []byte) []byte {
+	sealed := make([]byte, 1+c.aead.NonceSize(), 1+c.aead.NonceSize()+len(der)+c.aead.Overhead())
+	sealed[0] = sealedKeyVersion
+	rand.Read(sealed[1:])
+	return c.aead.Seal(sealed, sealed[1:], der, []byte(id))
+}
+
+// Open decrypts a key sealed by Seal for id.
+func (c *KeyCipher) Open(id string, sealed []byte) ([]byte, error) {
+	if len(sealed) == 0 || sealed[0] != sealedKeyVersion {
+		return nil, ErrKeyNotSealed
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < 1+nonceSize {
+		return nil, ErrKeySealed
+	}
+	der, err := c.aead.Open(nil, sealed[1:1+nonceSize], sealed[1+nonceSize:], []byte(id))
+	if err != nil {
+		return nil, ErrKeySealed
+	}
+	return der, nil
+}
@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms a Keyring can sign with.
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+const (
+	rsaKeyBits = 2048
+	// unknownKeyReloadInterval limits how often a token signed with a key
+	// the keyring has not seen makes it reload from its store.
+	unknownKeyReloadInterval = 10 * time.Second
+	storeTimeout             = 5 * time.Second
+)
+
+var ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+
+// SigningKey is a private key in a Keyring, named by the kid header of the
+// tokens it signs.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	// Key is an ed25519.PrivateKey for EdDSA or an *rsa.PrivateKey for
+	// RS256.
+	Key       crypto.Signer
+	CreatedAt time.Time
+	// RetiresAt is zero while the key may sign. Once a newer key takes
+	// over, it is the end of the overlap window during which tokens the
+	// key signed are still accepted.
+	RetiresAt time.Time
+}
+
+func (k SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// GenerateSigningKey makes a new key for algorithm with a random ID.
+func GenerateSigningKey(algorithm string) (SigningKey, error) {
+	var key crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return SigningKey{}, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+	return SigningKey{
+		ID:        hex.EncodeToString(id),
+		Algorithm: algorithm,
+		Key:       key,
+	}, nil
+}
+
+// MarshalSigningKey encodes a private key as PKCS #8 DER for storage.
+func MarshalSigningKey(key crypto.Signer) ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(key)
+}
+
+// ParseSigningKey decodes a private key stored by MarshalSigningKey.
+func ParseSigningKey(der []byte) (crypto.Signer, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, key)
+}
+
+// KeyStore is where a Keyring keeps its keys, so that every server signs
+// with the same keys and rotations survive restarts.
+type KeyStore interface {
+	// Load returns the keys that have not retired yet, oldest first.
+	Load(ctx context.Context) ([]SigningKey, error)
+	// Add stores a new key and sets the RetiresAt of every other key
+	// that is still signing to retireOthersAt.
+	Add(ctx context.Context, key SigningKey, retireOthersAt time.Time) error
+	// FirstCreatedAt returns when the oldest key was made, including keys
+	// that have retired, or the zero time if there are none yet.
+	FirstCreatedAt(ctx context.Context) (time.Time, error)
+}
+
+// Keyring signs access tokens with its newest key and accepts tokens signed
+// by any key that has not retired, picking the key by the token's kid.
+type Keyring struct {
+	store     KeyStore
+	algorithm string
+	overlap   time.Duration
+
+	// LegacySecret, if set, is the HS256 secret tokens were signed with
+	// before keys had IDs. Tokens without a kid are checked against it so
+	// that those already handed out keep working until they expire, but
+	// the secret cannot be used to make new ones: a legacy token is only
+	// accepted if it was issued before the first signing key was made, for
+	// no more than LegacyMaxAge, and never after LegacyMaxAge past that.
+	// Legacy tokens never had scopes, so one with a scope claim is
+	// refused, and the rest get UserScopes.
+	LegacySecret string
+	// LegacyMaxAge is the longest legacy tokens were issued for.
+	LegacyMaxAge time.Duration
+
+	// Now returns the current time. Tests can replace it; it defaults to
+	// time.Now.
+	Now func() time.Time
+
+	mu       sync.RWMutex
+	keys     []SigningKey
+	loadedAt time.Time
+	// introducedAt is when the first signing key was made, which does not
+	// change when the server restarts. Legacy tokens must have been issued
+	// before it.
+	introducedAt time.Time
+}
+
+// NewKeyring returns an empty keyring that generates algorithm keys and,
+// on rotation, keeps accepting the old keys for overlap. Call Load before
+// using it.
+func NewKeyring(store KeyStore, algorithm string, overlap time.Duration) (*Keyring, error) {
+	if algorithm != AlgEdDSA && algorithm != AlgRS256 {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+	return &Keyring{
+		store:     store,
+		algorithm: algorithm,
+		overlap:   overlap,
+		Now:       time.Now,
+	}, nil
+}
+
+// Load reads the keys from the store, generating a first key if none of
+// them can sign.
+func (k *Keyring) Load(ctx context.Context) error {
+	if err := k.reload(ctx); err != nil {
+		return err
+	}
+	if _, err := k.CurrentKey(); err == nil {
+		return nil
+	}
+	_, err := k.Rotate(ctx, k.algorithm)
+	return err
+}
+
+func (k *Keyring) reload(ctx context.Context) error {
+	keys, err := k.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	k.mu.RLock()
+	introducedAt := k.introducedAt
+	k.mu.RUnlock()
+	if introducedAt.IsZero() {
+		if introducedAt, err = k.store.FirstCreatedAt(ctx); err != nil {
+			return err
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	k.loadedAt = k.Now()
+	k.introducedAt = introducedAt
+	return nil
+}
+
+// Run reloads the keys every interval until ctx is done, so that a rotation
+// made by another server or the command line is picked up.
+func (k *Keyring) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.reload(ctx); err != nil {
+				log.Printf("Could not reload signing keys: %s", err)
+			}
+		}
+	}
+}
+
+// Rotate generates a new key for algorithm, or the keyring's algorithm if
+// it is empty, and signs with it from now on. The keys it replaces are
+// accepted for the overlap window and then retire.
+func (k *Keyring) Rotate(ctx context.Context, algorithm string) (SigningKey, error) {
+	if algorithm == "" {
+		algorithm = k.algorithm
+	}
+	key, err := GenerateSigningKey(algorithm)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	if err := k.store.Add(ctx, key, k.Now().Add(k.overlap)); err != nil {
+		return SigningKey{}, err
+	}
+	if err := k.reload(ctx); err != nil {
+		return SigningKey{}, err
+	}
+
+	for _, loaded := range k.Keys() {
+		if loaded.ID == key.ID {
+			return loaded, nil
+		}
+	}
+	return SigningKey{}, errors.New("rotated key was not stored")
+}
+
+// Keys returns the keys that have not retired, oldest first.
+func (k *Keyring) Keys() []SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := k.Now()
+	keys := make([]SigningKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		if key.RetiresAt.IsZero() || key.RetiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// CurrentKey is the key new tokens are signed with: the newest one that has
+// not been replaced.
+func (k *Keyring) CurrentKey() (SigningKey, error) {
+	keys := k.Keys()
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i].RetiresAt.IsZero() {
+			return keys[i], nil
+		}
+	}
+	return SigningKey{}, errors.New("no signing key")
+}
+
+// MakeAccessToken signs an access token saying tok with the current key.
+func (k *Keyring) MakeAccessToken(tok AccessToken, expiresIn time.Duration) (string, error) {
+	key, err := k.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+
+	newJWT := jwt.NewWithClaims(key.method(), newClaims(tok, expiresIn))
+	newJWT.Header["kid"] = key.ID
+	return newJWT.SignedString(key.Key)
+}
+
+// ParseAccessToken checks a token signed by any key in the keyring and
+// returns what it says.
+func (k *Keyring) ParseAccessToken(tokenString string) (AccessToken, error) {
+	return parseJWT(tokenString, k.keyFunc)
+}
+
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if k.LegacySecret == "" {
+			return nil, errors.New("token has no key id")
+		}
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		if err := k.checkLegacyClaims(token); err != nil {
+			return nil, err
+		}
+		return []byte(k.LegacySecret), nil
+	}
+
+	key, ok := k.verificationKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Key.Public(), nil
+}
+
+// checkLegacyClaims refuses a token without a kid unless it could have been
+// issued before keys were introduced, as LegacySecret describes.
+func (k *Keyring) checkLegacyClaims(token *jwt.Token) error {
+	claims, ok := token.Claims.(*Claims)
+	if !ok || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return errors.New("legacy token has no issue or expiry time")
+	}
+	if claims.Scope != "" {
+		return errors.New("legacy token has scopes")
+	}
+
+	k.mu.RLock()
+	introducedAt := k.introducedAt
+	k.mu.RUnlock()
+	issuedAt, expiresAt := claims.IssuedAt.Time, claims.ExpiresAt.Time
+	switch {
+	case introducedAt.IsZero() || !issuedAt.Before(introducedAt):
+		return errors.New("legacy token issued after signing keys were introduced")
+	case expiresAt.Sub(issuedAt) > k.LegacyMaxAge:
+		return errors.New("legacy token lasts too long")
+	case !k.Now().Before(introducedAt.Add(k.LegacyMaxAge)):
+		return errors.New("legacy tokens are no longer accepted")
+	}
+	return nil
+}
+
+// verificationKey finds the key with the given ID. A key it does not know
+// may have just been made by another server, so it reloads before giving
+// up, though not more than once every unknownKeyReloadInterval.
+func (k *Keyring) verificationKey(kid string) (SigningKey, bool) {
+	if key, ok := k.findKey(kid); ok {
+		return key, true
+	}
+
+	k.mu.RLock()
+	stale := k.Now().Sub(k.loadedAt) >= unknownKeyReloadInterval
+	k.mu.RUnlock()
+	if !stale {
+		return SigningKey{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := k.reload(ctx); err != nil {
+		log.Printf("Could not reload signing keys: %s", err)
+		return SigningKey{}, false
+	}
+	return k.findKey(kid)
+}
+
+func (k *Keyring) findKey(kid string) (SigningKey, bool) {
+	for _, key := range k.Keys() {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// memKeyStore is a KeyStore that keeps keys in memory, retiring them by
+// the clock of the keyring under test.
+type memKeyStore struct {
+	mu   sync.Mutex
+	now  func() time.Time
+	keys []SigningKey
+}
+
+func (s *memKeyStore) Load(ctx context.Context) ([]SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []SigningKey
+	for _, key := range s.keys {
+		if key.RetiresAt.IsZero() || key.RetiresAt.After(s.now()) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memKeyStore) Add(ctx context.Context, key SigningKey, retireOthersAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.keys {
+		if s.keys[i].RetiresAt.IsZero() {
+			s.keys[i].RetiresAt = retireOthersAt
+		}
+	}
+	key.CreatedAt = s.now()
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *memKeyStore) FirstCreatedAt(ctx context.Context) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.keys) == 0 {
+		return time.Time{}, nil
+	}
+	return s.keys[0].CreatedAt, nil
+}
+
+func newTestKeyring(t *testing.T, algorithm string) (*Keyring, *memKeyStore, *time.Time) {
+	t.Helper()
+	now := time.Now()
+	clock := func() time.Time { return now }
+	store := &memKeyStore{now: clock}
+	k, err := NewKeyring(store, algorithm, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	k.Now = clock
+	if err := k.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return k, store, &now
+}
+
+func TestKeyringSignAndValidate(t *testing.T) {
+	for _, alg := range []string{AlgEdDSA, AlgRS256} {
+		t.Run(alg, func(t *testing.T) {
+			k, _, _ := newTestKeyring(t, alg)
+			userID := uuid.New()
+			sessionID := uuid.New()
+
+			token, err := k.MakeAccessToken(AccessToken{UserID: userID, SessionID: sessionID, Version: 3}, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeAccessToken() error = %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+			if parsed.Header["alg"] != alg || parsed.Header["kid"] != k.Keys()[0].ID {
+				t.Errorf("token header = %v, want alg %s and kid %s", parsed.Header, alg, k.Keys()[0].ID)
+			}
+
+			got, err := k.ParseAccessToken(token)
+			if err != nil {
+				t.Fatalf("ParseAccessToken() error = %v", err)
+			}
+			if got.ID == "" || got.UserID != userID || got.SessionID != sessionID || got.Version != 3 {
+				t.Errorf("ParseAccessToken() = %+v, want a jti, user %v, session %v and version 3", got, userID, sessionID)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	k, _, now := newTestKeyring(t, AlgEdDSA)
+	userID := uuid.New()
+
+	before, _ := k.MakeAccessToken(AccessToken{UserID: userID}, 3*time.Hour)
+	oldKey := k.Keys()[0]
+	newKey, err := k.Rotate(context.Background(), AlgRS256)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	after, _ := k.MakeAccessToken(AccessToken{UserID: userID}, 3*time.Hour)
+
+	if jwks := k.JWKS(); len(jwks.Keys) != 2 || jwks.Keys[0].KeyID != oldKey.ID || jwks.Keys[1].KeyID != newKey.ID {
+		t.Errorf("JWKS() after rotation = %+v, want %s then %s", jwks, oldKey.ID, newKey.ID)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		advance time.Duration
+		wantErr bool
+	}{
+		{name: "Old key during overlap", token: before, advance: 0, wantErr: false},
+		{name: "New key", token: after, advance: 0, wantErr: false},
+		{name: "Old key after overlap", token: before, advance: 2 * time.Hour, wantErr: true},
+		{name: "New key after overlap", token: after, advance: 2 * time.Hour, wantErr: false},
+	}
+	start := *now
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*now = start.Add(tt.advance)
+			if _, err := k.ParseAccessToken(tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("ParseAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if jwks := k.JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].KeyType != "RSA" {
+		t.Errorf("JWKS() after overlap = %+v, want just the RSA key", jwks)
+	}
+}
+
+func TestKeyringPicksUpRotationElsewhere(t *testing.T) {
+	k, store, now := newTestKeyring(t, AlgEdDSA)
+	other, err := NewKeyring(store, AlgEdDSA, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	other.Now = k.Now
+	if err := other.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := other.Rotate(context.Background(), ""); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	token, _ := other.MakeAccessToken(AccessToken{UserID: uuid.New()}, time.Hour)
+
+	if _, err := k.ParseAccessToken(token); err == nil {
+		t.Errorf("ParseAccessToken() right after loading error = nil, want unknown key")
+	}
+	*now = now.Add(unknownKeyReloadInterval)
+	if _, err := k.ParseAccessToken(token); err != nil {
+		t.Errorf("ParseAccessToken() with a key rotated elsewhere error = %v", err)
+	}
+}
+
+func TestKeyringRejects(t *testing.T) {
+	k, store, now := newTestKeyring(t, AlgEdDSA)
+	k.LegacySecret = "secret"
+	k.LegacyMaxAge = time.Hour
+	userID := uuid.New()
+
+	// legacyToken signs a kid-less HS256 token issued at issuedAt and
+	// lasting lifetime.
+	legacyToken := func(secret string, issuedAt time.Time, lifetime time.Duration, scopes ...string) string {
+		claims := newClaims(AccessToken{UserID: userID, Scopes: scopes}, lifetime)
+		claims.IssuedAt = jwt.NewNumericDate(issuedAt)
+		claims.ExpiresAt = jwt.NewNumericDate(issuedAt.Add(lifetime))
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return token
+	}
+	beforeStart := now.Add(-time.Minute)
+	legacy := legacyToken("secret", beforeStart, time.Hour)
+	wrongSecret := legacyToken("wrong_secret", beforeStart, time.Hour)
+	// With the secret, anyone could make these.
+	issuedAfterStart := legacyToken("secret", now.Add(time.Second), time.Hour)
+	longLived := legacyToken("secret", beforeStart, 24*time.Hour)
+	legacyAdmin := legacyToken("secret", beforeStart, time.Hour, ScopeAdmin)
+	expired, _ := k.MakeAccessToken(AccessToken{UserID: userID}, -time.Hour)
+	otherKeyring, _, _ := newTestKeyring(t, AlgEdDSA)
+	foreign, _ := otherKeyring.MakeAccessToken(AccessToken{UserID: userID}, time.Hour)
+
+	// A token that claims the keyring's kid but is signed with the
+	// legacy HMAC secret must not be accepted.
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(AccessToken{UserID: userID}, time.Hour))
+	confused.Header["kid"] = k.Keys()[0].ID
+	algConfusion, _ := confused.SignedString([]byte("secret"))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "Legacy HS256 token", token: legacy, wantErr: false},
+		{name: "Legacy token with the wrong secret", token: wrongSecret, wantErr: true},
+		{name: "Legacy token issued after keys", token: issuedAfterStart, wantErr: true},
+		{name: "Legacy token lasting too long", token: longLived, wantErr: true},
+		{name: "Legacy token with scopes", token: legacyAdmin, wantErr: true},
+		{name: "Expired token", token: expired, wantErr: true},
+		{name: "Token from another keyring", token: foreign, wantErr: true},
+		{name: "HS256 token with a kid", token: algConfusion, wantErr: true},
+		{name: "Invalid string", token: "not.a.jwt", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k.ParseAccessToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.UserID != userID {
+				t.Errorf("ParseAccessToken() userID = %v, want %v", got.UserID, userID)
+			}
+		})
+	}
+
+	if got, _ := k.ParseAccessToken(legacy); got.HasScope(ScopeAdmin) {
+		t.Errorf("ParseAccessToken() legacy token scopes = %v, want no admin", got.Scopes)
+	}
+	*now = now.Add(k.LegacyMaxAge)
+	if _, err := k.ParseAccessToken(legacy); err == nil {
+		t.Errorf("ParseAccessToken() legacy token after LegacyMaxAge error = nil, want an error")
+	}
+
+	// Restarting does not reopen the window, which runs from the first key
+	// rather than from when the keyring was loaded.
+	restarted, err := NewKeyring(store, AlgEdDSA, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	restarted.Now = k.Now
+	restarted.LegacySecret = k.LegacySecret
+	restarted.LegacyMaxAge = k.LegacyMaxAge
+	if err := restarted.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := restarted.ParseAccessToken(legacyToken("secret", now.Add(-time.Minute), time.Hour)); err == nil {
+		t.Errorf("ParseAccessToken() legacy token after a restart error = nil, want an error")
+	}
+}
+
+func TestSigningKeyRoundTrip(t *testing.T) {
+	for _, alg := range []string{AlgEdDSA, AlgRS256} {
+		t.Run(alg, func(t *testing.T) {
+			key, err := GenerateSigningKey(alg)
+			if err != nil {
+				t.Fatalf("GenerateSigningKey() error = %v", err)
+			}
+			der, err := MarshalSigningKey(key.Key)
+			if err != nil {
+				t.Fatalf("MarshalSigningKey() error = %v", err)
+			}
+			parsed, err := ParseSigningKey(der)
+			if err != nil {
+				t.Fatalf("ParseSigningKey() error = %v", err)
+			}
+			if !parsed.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(key.Key.Public()) {
+				t.Errorf("ParseSigningKey() public key differs from the original")
+			}
+		})
+	}
+
+	if _, err := GenerateSigningKey("HS256"); err == nil {
+		t.Errorf("GenerateSigningKey(HS256) error = nil, want ErrUnsupportedAlgorithm")
+	}
+}
+
+func TestKeyCipher(t *testing.T) {
+	c, err := NewKeyCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyCipher() error = %v", err)
+	}
+	other, _ := NewKeyCipher(bytes.Repeat([]byte{2}, 32))
+	key, _ := GenerateSigningKey(AlgEdDSA)
+	der, _ := MarshalSigningKey(key.Key)
+
+	sealed := c.Seal(key.ID, der)
+	if bytes.Contains(sealed, der) {
+		t.Fatalf("Seal() output contains the key")
+	}
+	opened, err := c.Open(key.ID, sealed)
+	if err != nil || !bytes.Equal(opened, der) {
+		t.Errorf("Open() = %x, %v, want the key back", opened, err)
+	}
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	for _, tc := range []struct {
+		name   string
+		cipher *KeyCipher
+		id     string
+		stored []byte
+		want   error
+	}{
+		{"Other cipher key", other, key.ID, sealed, ErrKeySealed},
+		{"Other key ID", c, "other", sealed, ErrKeySealed},
+		{"Tampered", c, key.ID, tampered, ErrKeySealed},
+		{"Truncated", c, key.ID, sealed[:5], ErrKeySealed},
+		{"Not sealed", c, key.ID, der, ErrKeyNotSealed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.cipher.Open(tc.id, tc.stored); !errors.Is(err, tc.want) {
+				t.Errorf("Open() error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	if _, err := NewKeyCipher(make([]byte, 16)); err == nil {
+		t.Errorf("NewKeyCipher(16 bytes) error = nil, want an error")
+	}
+}
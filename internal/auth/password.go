@@ -1,25 +1,75 @@
 package auth
 
 import (
-	"log"
+	"errors"
+	"fmt"
 
 	"github.com/alexedwards/argon2id"
 )
 
-func HashPassword(password string) (string, error) {
-	hashed_pswd, err := argon2id.CreateHash(password, argon2id.DefaultParams)
-	if err != nil {
-		log.Fatal(err)
+// PasswordParams are the argon2id parameters passwords are hashed with.
+// Memory is in KiB.
+type PasswordParams argon2id.Params
+
+// DefaultPasswordParams are the second recommended option of RFC 9106:
+// 64 MiB of memory, 3 passes and 4 lanes.
+var DefaultPasswordParams = PasswordParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var errPasswordParams = errors.New("invalid password hashing parameters")
+
+// Validate reports whether p can hash passwords: argon2id needs at least one
+// pass and one lane, and 8 KiB of memory for each lane, and the salt and key
+// must be long enough to be worth having.
+func (p PasswordParams) Validate() error {
+	switch {
+	case p.Iterations < 1:
+		return fmt.Errorf("%w: need at least 1 iteration", errPasswordParams)
+	case p.Parallelism < 1:
+		return fmt.Errorf("%w: need a parallelism of at least 1", errPasswordParams)
+	case p.Memory < 8*uint32(p.Parallelism):
+		return fmt.Errorf("%w: need at least %d KiB of memory for a parallelism of %d", errPasswordParams, 8*uint32(p.Parallelism), p.Parallelism)
+	case p.SaltLength < 8:
+		return fmt.Errorf("%w: need a salt of at least 8 bytes", errPasswordParams)
+	case p.KeyLength < 16:
+		return fmt.Errorf("%w: need a key of at least 16 bytes", errPasswordParams)
 	}
+	return nil
+}
 
-	return hashed_pswd, nil
+// Hash hashes password with p, in the PHC string format that records p
+// alongside the hash.
+func (p PasswordParams) Hash(password string) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	return argon2id.CreateHash(password, (*argon2id.Params)(&p))
 }
 
-func CheckPasswordHash(password, hash string) (bool, error) {
-	match, err := argon2id.ComparePasswordAndHash(password, hash)
+// Check reports whether password matches hash, which may have been made
+// with other parameters. If it matches but was made with other parameters,
+// rehash is true, and the password should be hashed again with p.
+func (p PasswordParams) Check(password, hash string) (match, rehash bool, err error) {
+	match, used, err := argon2id.CheckHash(password, hash)
 	if err != nil {
-		return false, err
+		return false, false, err
 	}
+	return match, match && PasswordParams(*used) != p, nil
+}
 
-	return match, nil
+// HashPassword hashes password with DefaultPasswordParams.
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordParams.Hash(password)
+}
+
+// CheckPasswordHash reports whether password matches hash, whatever
+// parameters it was made with.
+func CheckPasswordHash(password, hash string) (bool, error) {
+	match, _, err := DefaultPasswordParams.Check(password, hash)
+	return match, err
 }
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// PasswordPolicy is what a new password has to be.
+type PasswordPolicy struct {
+	// MinLength is the fewest characters a password may have.
+	MinLength int
+	// MinEntropy is the fewest bits PasswordEntropy may give it.
+	MinEntropy float64
+	// Breached, if set, lists passwords that have been leaked and may not
+	// be used.
+	Breached BreachedPasswords
+}
+
+// DefaultPasswordPolicy asks for 8 characters, as NIST SP 800-63B does,
+// that would take around 2^30 guesses, and checks no breach list.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  8,
+	MinEntropy: 30,
+}
+
+// Reasons a password is rejected, for clients to tell apart.
+const (
+	PasswordTooShort = "too_short"
+	PasswordTooWeak  = "too_weak"
+	PasswordBreached = "breached"
+)
+
+// A PasswordProblem is a reason a password was rejected.
+type PasswordProblem struct {
+	// Code is one of PasswordTooShort, PasswordTooWeak or PasswordBreached.
+	Code    string
+	Message string
+}
+
+// Check returns the ways password falls short of p, or none if it is fine.
+// userInputs are the user's own details, such as their email and handle,
+// which make a password easy to guess. The error is only for failing to
+// check the breach list.
+func (p PasswordPolicy) Check(ctx context.Context, password string, userInputs ...string) ([]PasswordProblem, error) {
+	var problems []PasswordProblem
+	if utf8.RuneCountInString(password) < p.MinLength {
+		problems = append(problems, PasswordProblem{
+			Code:    PasswordTooShort,
+			Message: fmt.Sprintf("Passwords must be at least %d characters", p.MinLength),
+		})
+	} else if PasswordEntropy(password, userInputs...) < p.MinEntropy {
+		problems = append(problems, PasswordProblem{
+			Code:    PasswordTooWeak,
+			Message: "This password would be too easy to guess",
+		})
+	}
+	if p.Breached != nil {
+		breached, err := IsBreachedPassword(ctx, p.Breached, password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			problems = append(problems, PasswordProblem{
+				Code:    PasswordBreached,
+				Message: "This password has appeared in a data breach",
+			})
+		}
+	}
+	return problems, nil
+}
+
+// BreachedPasswords lists the SHA-1 hashes of leaked passwords by their
+// first five hex digits, as the Pwned Passwords range API does. Only that
+// prefix is ever asked for, so a list kept by someone else learns nothing
+// useful about the password being checked.
+type BreachedPasswords interface {
+	// Range returns the last 35 hex digits, in upper case, of each hash
+	// that starts with prefix.
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// IsBreachedPassword reports whether password is in list.
+func IsBreachedPassword(ctx context.Context, list BreachedPasswords, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := list.Range(ctx, hash[:5])
+	if err != nil {
+		return false, fmt.Errorf("checking breached passwords: %w", err)
+	}
+	for _, suffix := range suffixes {
+		if suffix == hash[5:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
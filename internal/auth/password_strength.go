@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// maxEntropyRunes is how much of a password PasswordEntropy looks for
+// patterns in. Any more is counted as guessed a character at a time.
+const maxEntropyRunes = 100
+
+// commonPasswords are some of the most used passwords and password words,
+// most used first. Sequences and keyboard runs like 123456 and qwerty are
+// left to their own patterns.
+var commonPasswords = []string{
+	"password", "iloveyou", "princess", "rockyou", "abc", "monkey",
+	"babygirl", "lovely", "michael", "ashley", "jessica", "daniel",
+	"sunshine", "chocolate", "football", "baseball", "soccer", "anthony",
+	"friends", "butterfly", "purple", "angel", "jordan", "liverpool",
+	"justin", "loveme", "secret", "letmein", "dragon", "master", "welcome",
+	"shadow", "superman", "batman", "trustno", "hello", "freedom",
+	"whatever", "charlie", "starwars", "login", "admin", "passw", "pass",
+	"love", "summer", "winter", "spring", "autumn", "flower", "hunter",
+	"killer", "pokemon", "computer", "internet", "samsung", "google",
+	"cheese", "orange", "banana", "cookie", "family", "forever", "mustang",
+	"access", "ninja", "qazwsx", "zaq", "chirpy", "chirp", "birdie",
+}
+
+var commonPasswordRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonPasswords))
+	for i, word := range commonPasswords {
+		ranks[word] = i + 1
+	}
+	return ranks
+}()
+
+// keyboardRows are the rows of a US keyboard, unshifted.
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+// unleet undoes the substitutions people make for letters in words.
+var unleet = map[rune]rune{'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i'}
+
+// PasswordEntropy estimates, in bits, how many guesses password would take
+// someone who knows how people choose passwords. Like zxcvbn, it finds the
+// cheapest way to build the password out of common passwords, the user's
+// own details in userInputs (such as their email and handle), keyboard
+// runs, sequences, repeated characters and years, with whatever is left
+// over guessed a character at a time, and adds up the bits of each piece.
+func PasswordEntropy(password string, userInputs ...string) float64 {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+	charBits := math.Log2(float64(cardinality(runes)))
+	rest := runes[min(len(runes), maxEntropyRunes):]
+	runes = runes[:min(len(runes), maxEntropyRunes)]
+
+	ending := make([][]patternMatch, len(runes)+1)
+	for _, m := range findPatterns(runes, userInputs) {
+		ending[m.j] = append(ending[m.j], m)
+	}
+	best := make([]float64, len(runes)+1)
+	for j := 1; j <= len(runes); j++ {
+		best[j] = best[j-1] + charBits
+		for _, m := range ending[j] {
+			best[j] = min(best[j], best[m.i]+m.bits)
+		}
+	}
+	return best[len(runes)] + float64(len(rest))*charBits
+}
+
+// A patternMatch is runes[i:j] of a password matching a pattern that takes
+// bits to guess.
+type patternMatch struct {
+	i, j int
+	bits float64
+}
+
+func findPatterns(runes []rune, userInputs []string) []patternMatch {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	var matches []patternMatch
+	matches = append(matches, wordPatterns(runes, lower, userInputs)...)
+	matches = append(matches, repeatPatterns(lower)...)
+	matches = append(matches, sequencePatterns(lower)...)
+	matches = append(matches, keyboardPatterns(lower)...)
+	matches = append(matches, yearPatterns(runes)...)
+	return matches
+}
+
+// wordPatterns finds common passwords and the user's details, forwards or
+// backwards, with capitals and letters swapped for look-alike symbols
+// costing a bit each.
+func wordPatterns(runes, lower []rune, userInputs []string) []patternMatch {
+	ranks := commonPasswordRanks
+	if len(userInputs) > 0 {
+		ranks = make(map[string]int, len(commonPasswordRanks)+len(userInputs))
+		for word, rank := range commonPasswordRanks {
+			ranks[word] = rank
+		}
+		for _, input := range userInputs {
+			for _, word := range strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			}) {
+				ranks[word] = 1
+			}
+		}
+	}
+	longest := 0
+	for word := range ranks {
+		longest = max(longest, len([]rune(word)))
+	}
+
+	var matches []patternMatch
+	for i := range lower {
+		for j := i + 3; j <= min(len(lower), i+longest); j++ {
+			substitutions := 0
+			word := make([]rune, 0, j-i)
+			for _, r := range lower[i:j] {
+				if l, ok := unleet[r]; ok {
+					r = l
+					substitutions++
+				}
+				word = append(word, r)
+			}
+			capitals := capitalBits(runes[i:j])
+			if rank, ok := ranks[string(lower[i:j])]; ok {
+				matches = append(matches, patternMatch{i, j, math.Log2(float64(rank)) + capitals})
+			}
+			if rank, ok := ranks[string(word)]; ok && substitutions > 0 {
+				matches = append(matches, patternMatch{i, j, math.Log2(float64(rank)) + capitals + float64(substitutions)})
+			}
+			if rank, ok := ranks[reverse(string(word))]; ok {
+				matches = append(matches, patternMatch{i, j, math.Log2(float64(rank)) + capitals + float64(substitutions) + 1})
+			}
+		}
+	}
+	return matches
+}
+
+// capitalBits is what capitalising a word costs: a bit for only the first
+// letter or all of them, and a bit a letter otherwise.
+func capitalBits(word []rune) float64 {
+	upper, letters := 0, 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 0
+	case upper == letters || (upper == 1 && unicode.IsUpper(word[0])):
+		return 1
+	default:
+		return float64(upper)
+	}
+}
+
+// repeatPatterns finds a character repeated three or more times.
+func repeatPatterns(lower []rune) []patternMatch {
+	var matches []patternMatch
+	for i := 0; i < len(lower); {
+		j := i + 1
+		for j < len(lower) && lower[j] == lower[i] {
+			j++
+		}
+		for k := i + 3; k <= j; k++ {
+			matches = append(matches, patternMatch{i, k, math.Log2(float64(cardinality(lower[i:i+1]))) + math.Log2(float64(k-i))})
+		}
+		i = j
+	}
+	return matches
+}
+
+// sequencePatterns finds runs like abcd, 4321 and 2468.
+func sequencePatterns(lower []rune) []patternMatch {
+	var matches []patternMatch
+	for i := 0; i+2 < len(lower); i++ {
+		step := lower[i+1] - lower[i]
+		if step == 0 || step < -2 || step > 2 {
+			continue
+		}
+		for j := i + 2; j < len(lower) && lower[j]-lower[j-1] == step; j++ {
+			bits := math.Log2(float64(cardinality(lower[i:i+1]))) + math.Log2(float64(j+1-i))
+			if step != 1 {
+				bits++
+			}
+			matches = append(matches, patternMatch{i, j + 1, bits})
+		}
+	}
+	return matches
+}
+
+// keyboardPatterns finds runs along a row of the keyboard, either way.
+func keyboardPatterns(lower []rune) []patternMatch {
+	keys := 0
+	for _, row := range keyboardRows {
+		keys += len(row)
+	}
+	var matches []patternMatch
+	for i := range lower {
+		for j := i + 3; j <= len(lower); j++ {
+			run := string(lower[i:j])
+			bits := math.Log2(float64(keys)) + math.Log2(float64(j-i))
+			var forward, backward bool
+			for _, row := range keyboardRows {
+				forward = forward || strings.Contains(row, run)
+				backward = backward || strings.Contains(row, reverse(run))
+			}
+			if forward {
+				matches = append(matches, patternMatch{i, j, bits})
+			} else if backward {
+				matches = append(matches, patternMatch{i, j, bits + 1})
+			} else {
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// yearPatterns finds years from 1900 to 2099.
+func yearPatterns(runes []rune) []patternMatch {
+	var matches []patternMatch
+	for i := 0; i+4 <= len(runes); i++ {
+		s := string(runes[i : i+4])
+		if (strings.HasPrefix(s, "19") || strings.HasPrefix(s, "20")) && strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) < 0 {
+			matches = append(matches, patternMatch{i, i + 4, math.Log2(200)})
+		}
+	}
+	return matches
+}
+
+// cardinality is how many characters someone guessing runes a character at
+// a time would try for each: those of every kind that runes uses.
+func cardinality(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, kind := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if kind.used {
+			n += kind.size
+		}
+	}
+	return n
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for l, r := 0, len(runes)-1; l < r; l, r = l+1, r-1 {
+		runes[l], runes[r] = runes[r], runes[l]
+	}
+	return string(runes)
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 )
 
@@ -11,3 +12,12 @@ func MakeRefreshToken() (string, error) {
 	r_token := hex.EncodeToString(key)
 	return r_token, nil
 }
+
+// HashRefreshToken returns the hex SHA-256 of a refresh token, which is
+// what gets stored and looked up instead of the token itself. The tokens
+// are 256 random bits, so a plain hash is as hard to reverse as guessing
+// the token.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
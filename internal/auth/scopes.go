@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Scopes an access token can carry. A request is only allowed if the token
+// it was made with has the scope its route requires.
+const (
+	ScopeChirpsRead   = "chirps:read"
+	ScopeChirpsWrite  = "chirps:write"
+	ScopeAccountWrite = "account:write"
+	ScopeAdmin        = "admin"
+)
+
+var ErrUnknownScope = errors.New("unknown scope")
+
+// UserScopes are the scopes a user's own session tokens carry. Tokens made
+// before scopes existed have no scope claim and are read as having these.
+var UserScopes = []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeAccountWrite}
+
+var knownScopes = map[string]bool{
+	ScopeChirpsRead:   true,
+	ScopeChirpsWrite:  true,
+	ScopeAccountWrite: true,
+	ScopeAdmin:        true,
+}
+
+// ParseScopes splits a space-separated scope claim, as in RFC 8693, into
+// its scopes, dropping duplicates and rejecting scopes it does not know.
+func ParseScopes(s string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range strings.Fields(s) {
+		if !knownScopes[scope] {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// HasScope reports whether scopes includes scope.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopes reports whether scopes includes every scope in want.
+func HasScopes(scopes, want []string) bool {
+	for _, scope := range want {
+		if !HasScope(scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -7,29 +7,43 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	InReplyTo  uuid.NullUUID
+	Kind       string
+	RefChirpID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.Kind,
+		arg.RefChirpID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,22 +51,86 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RefChirpID,
 	)
 	return i, err
 }
 
 const deleteChirpById = `-- name: DeleteChirpById :exec
 DELETE FROM chirps
-WHERE id = $1
+WHERE id = $1 OR (kind = 'rechirp' AND ref_chirp_id = $1)
 `
 
+// Rechirps have nothing of their own to show, so they go with the chirp
+// they repost.
 func (q *Queries) DeleteChirpById(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, deleteChirpById, id)
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE kind = 'rechirp' AND user_id = $1 AND ref_chirp_id = $2
+`
+
+type DeleteRechirpParams struct {
+	UserID     uuid.UUID
+	RefChirpID uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RefChirpID)
+	return err
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.created_at, parent.updated_at, parent.body, parent.user_id, parent.in_reply_to, parent.kind, parent.ref_chirp_id, 1 AS depth FROM chirps AS parent
+    WHERE parent.id = (SELECT c.in_reply_to FROM chirps AS c WHERE c.id = $1)
+    UNION ALL
+    SELECT parent.id, parent.created_at, parent.updated_at, parent.body, parent.user_id, parent.in_reply_to, parent.kind, parent.ref_chirp_id, a.depth + 1 FROM chirps AS parent
+    JOIN ancestors AS a ON parent.id = a.in_reply_to
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM ancestors
+ORDER BY depth DESC
+`
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RefChirpID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM chirps
 WHERE id = $1
 `
 
@@ -65,17 +143,35 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RefChirpID,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT reply.id, reply.created_at, reply.updated_at, reply.body, reply.user_id, reply.in_reply_to, reply.kind, reply.ref_chirp_id, 1 AS depth FROM chirps AS reply
+    WHERE reply.in_reply_to = $1
+    UNION ALL
+    SELECT reply.id, reply.created_at, reply.updated_at, reply.body, reply.user_id, reply.in_reply_to, reply.kind, reply.ref_chirp_id, d.depth + 1 FROM chirps AS reply
+    JOIN descendants AS d ON reply.in_reply_to = d.id
+    WHERE d.depth < $2::int
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM descendants
+ORDER BY created_at ASC, id ASC
+LIMIT $3
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type GetChirpDescendantsParams struct {
+	ChirpID  uuid.UUID
+	MaxDepth int32
+	Limit    int32
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.ChirpID, arg.MaxDepth, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +185,9 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RefChirpID,
 		); err != nil {
 			return nil, err
 		}
@@ -103,14 +202,13 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpsByAuthor = `-- name: GetChirpsByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY created_at
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM chirps
+WHERE id = ANY($1::uuid[])
 `
 
-func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByAuthor, userID)
+func (q *Queries) GetChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +222,299 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RefChirpID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRechirp = `-- name: GetRechirp :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM chirps
+WHERE kind = 'rechirp' AND user_id = $1 AND ref_chirp_id = $2
+`
+
+type GetRechirpParams struct {
+	UserID     uuid.UUID
+	RefChirpID uuid.NullUUID
+}
+
+func (q *Queries) GetRechirp(ctx context.Context, arg GetRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getRechirp, arg.UserID, arg.RefChirpID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.RefChirpID,
+	)
+	return i, err
+}
+
+const getRechirpCounts = `-- name: GetRechirpCounts :many
+SELECT ref_chirp_id, COUNT(*) AS rechirp_count FROM chirps
+WHERE kind = 'rechirp' AND ref_chirp_id = ANY($1::uuid[])
+GROUP BY ref_chirp_id
+`
+
+type GetRechirpCountsRow struct {
+	RefChirpID   uuid.NullUUID
+	RechirpCount int64
+}
+
+func (q *Queries) GetRechirpCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetRechirpCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRechirpCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRechirpCountsRow
+	for rows.Next() {
+		var i GetRechirpCountsRow
+		if err := rows.Scan(
+			&i.RefChirpID,
+			&i.RechirpCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+    AND ($2::timestamp IS NULL
+        OR (created_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RefChirpID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+    AND ($2::timestamp IS NULL
+        OR (created_at, id) < ($2::timestamp, $3::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RefChirpID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReplies = `-- name: ListReplies :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id FROM chirps
+WHERE in_reply_to = $1
+    AND ($2::timestamp IS NULL
+        OR (created_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type ListRepliesParams struct {
+	ChirpID         uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListReplies(ctx context.Context, arg ListRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listReplies,
+		arg.ChirpID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.RefChirpID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.kind, chirps.ref_chirp_id,
+    ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', $1))::float8 AS rank
+FROM chirps
+WHERE to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', $1)
+    AND ($2::uuid IS NULL OR chirps.user_id = $2)
+    AND ($3::timestamp IS NULL OR chirps.created_at >= $3)
+    AND ($4::timestamp IS NULL OR chirps.created_at < $4)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT $5 OFFSET $6
+`
+
+type SearchChirpsParams struct {
+	Query    string
+	AuthorID uuid.NullUUID
+	Since    sql.NullTime
+	Until    sql.NullTime
+	Limit    int32
+	Offset   int32
+}
+
+type SearchChirpsRow struct {
+	Chirp Chirp
+	Rank  float64
+}
+
+// The query uses websearch_to_tsquery syntax: "quoted phrases", -excluded
+// words and OR.
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.Kind,
+			&i.Chirp.RefChirpID,
+			&i.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store is the set of queries the handlers depend on. *Queries satisfies it
+// against Postgres; internal/memstore provides an in-memory version for tests
+// and for running a local dev server without a database.
+type Store interface {
+	// api_keys.sql
+	CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error)
+	DeleteApiKey(ctx context.Context, arg DeleteApiKeyParams) (int64, error)
+	DeleteUserApiKeys(ctx context.Context, userID uuid.UUID) error
+	GetApiKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	ListApiKeys(ctx context.Context, userID uuid.UUID) ([]ApiKey, error)
+	TouchApiKey(ctx context.Context, id uuid.UUID) error
+
+	// banned_words.sql
+	AddBannedWord(ctx context.Context, word string) error
+	ListBannedWords(ctx context.Context) ([]string, error)
+	RemoveBannedWord(ctx context.Context, word string) error
+
+	// blocks.sql
+	BlockUser(ctx context.Context, arg BlockUserParams) error
+	DeleteBlockedRechirps(ctx context.Context, arg DeleteBlockedRechirpsParams) error
+	DetachBlockedQuotes(ctx context.Context, arg DetachBlockedQuotesParams) error
+	IsBlocked(ctx context.Context, arg IsBlockedParams) (bool, error)
+	UnblockUser(ctx context.Context, arg UnblockUserParams) error
+
+	// chirp_hashtags.sql
+	AddChirpHashtags(ctx context.Context, arg AddChirpHashtagsParams) error
+	GetTrendingHashtags(ctx context.Context, arg GetTrendingHashtagsParams) ([]GetTrendingHashtagsRow, error)
+	ListChirpsByHashtag(ctx context.Context, arg ListChirpsByHashtagParams) ([]Chirp, error)
+
+	// chirp_likes.sql
+	GetChirpLikeStats(ctx context.Context, arg GetChirpLikeStatsParams) ([]GetChirpLikeStatsRow, error)
+	LikeChirp(ctx context.Context, arg LikeChirpParams) (int64, error)
+	UnlikeChirp(ctx context.Context, arg UnlikeChirpParams) error
+
+	// chirp_mentions.sql
+	AddChirpMentions(ctx context.Context, arg AddChirpMentionsParams) error
+	GetChirpMentions(ctx context.Context, chirpIds []uuid.UUID) ([]ChirpMention, error)
+
+	// chirps.sql
+	CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error)
+	DeleteChirpById(ctx context.Context, id uuid.UUID) error
+	DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) error
+	GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error)
+	GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
+	GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error)
+	GetChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]Chirp, error)
+	GetRechirp(ctx context.Context, arg GetRechirpParams) (Chirp, error)
+	GetRechirpCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetRechirpCountsRow, error)
+	ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error)
+	ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error)
+	ListReplies(ctx context.Context, arg ListRepliesParams) ([]Chirp, error)
+	SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error)
+
+	// email_verifications.sql
+	CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) error
+	DeleteEmailVerifications(ctx context.Context, userID uuid.UUID) error
+	UseEmailVerification(ctx context.Context, arg UseEmailVerificationParams) (EmailVerification, error)
+
+	// flagged_chirps.sql
+	FlagChirp(ctx context.Context, arg FlagChirpParams) error
+	ListFlaggedChirps(ctx context.Context, arg ListFlaggedChirpsParams) ([]ListFlaggedChirpsRow, error)
+	UnflagChirp(ctx context.Context, chirpID uuid.UUID) error
+
+	// follows.sql
+	FollowUser(ctx context.Context, arg FollowUserParams) (int64, error)
+	GetTimeline(ctx context.Context, arg GetTimelineParams) ([]Chirp, error)
+	ListFollowers(ctx context.Context, arg ListFollowersParams) ([]ListFollowersRow, error)
+	ListFollowing(ctx context.Context, arg ListFollowingParams) ([]ListFollowingRow, error)
+	UnfollowUser(ctx context.Context, arg UnfollowUserParams) error
+
+	// login_failures.sql
+	DeleteLoginFailures(ctx context.Context, key string) error
+	DeleteStaleLoginFailures(ctx context.Context, lastFailedAt time.Time) error
+	GetLoginFailures(ctx context.Context, key string) (LoginFailure, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginFailure, error)
+
+	// mfa.sql
+	AdvanceUserTotpStep(ctx context.Context, arg AdvanceUserTotpStepParams) (int64, error)
+	AttemptMfaChallenge(ctx context.Context, arg AttemptMfaChallengeParams) (MfaChallenge, error)
+	ConfirmUserTotp(ctx context.Context, arg ConfirmUserTotpParams) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+	CreateMfaChallenge(ctx context.Context, arg CreateMfaChallengeParams) error
+	CreateRecoveryCodes(ctx context.Context, arg CreateRecoveryCodesParams) error
+	DeleteMfaChallenge(ctx context.Context, tokenHash string) error
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	DeleteUserMfaChallenges(ctx context.Context, userID uuid.UUID) error
+	DeleteUserTotp(ctx context.Context, userID uuid.UUID) error
+	GetUserTotp(ctx context.Context, userID uuid.UUID) (UserTotp, error)
+	SetPendingUserTotp(ctx context.Context, arg SetPendingUserTotpParams) (int64, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+
+	// notifications.sql
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) error
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]ListNotificationsRow, error)
+	MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error
+	MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) error
+
+	// password_resets.sql
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) error
+	DeletePasswordResets(ctx context.Context, userID uuid.UUID) error
+	UsePasswordReset(ctx context.Context, arg UsePasswordResetParams) (PasswordReset, error)
+
+	// refresh_tokens.sql
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
+	GetRefreshTokenReplacement(ctx context.Context, tokenHash string) (RefreshToken, error)
+	GetUserFromRefreshToken(ctx context.Context, tokenHash string) (User, error)
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]ListSessionsRow, error)
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, arg RevokeOtherSessionsParams) error
+	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error)
+
+	// signing_keys.sql
+	CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) (SigningKey, error)
+	GetFirstSigningKeyCreatedAt(ctx context.Context) (time.Time, error)
+	ListSigningKeys(ctx context.Context) ([]SigningKey, error)
+	RetireSigningKeys(ctx context.Context, arg RetireSigningKeysParams) error
+	UpdateSigningKeyPrivateKey(ctx context.Context, arg UpdateSigningKeyPrivateKeyParams) error
+
+	// users.sql
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAll(ctx context.Context) error
+	GetTokenVersion(ctx context.Context, id uuid.UUID) (int32, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserById(ctx context.Context, id uuid.UUID) (User, error)
+	GetUsersByHandles(ctx context.Context, handles []string) ([]User, error)
+	IncrementTokenVersion(ctx context.Context, id uuid.UUID) (int32, error)
+	PatchUser(ctx context.Context, arg PatchUserParams) (User, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error
+	SetPendingEmail(ctx context.Context, arg SetPendingEmailParams) error
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpgradeUser(ctx context.Context, id uuid.UUID) error
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
+}
+
+var _ Store = (*Queries)(nil)
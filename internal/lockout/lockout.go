@@ -0,0 +1,191 @@
+// Package lockout slows down password guessing. It counts failed logins per
+// account and per client IP, and once either has failed too often makes it
+// wait before trying again, doubling the wait with each further failure up
+// to a lockout.
+package lockout
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Policy is how many failures a key may have before it has to wait, and
+// how long it waits.
+type Policy struct {
+	// FreeAttempts is how many failures in a row are allowed without
+	// waiting.
+	FreeAttempts int32
+	// BaseDelay is the wait after the first failure over FreeAttempts. It
+	// doubles with each failure after that.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait. A key that has reached it is locked out.
+	MaxDelay time.Duration
+	// ForgetAfter is how long after its last failure a key starts again
+	// from nothing.
+	ForgetAfter time.Duration
+}
+
+// Delay is how long a key must wait after its failures-th failure in a row.
+func (p Policy) Delay(failures int32) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return min(delay, p.MaxDelay)
+}
+
+var (
+	// DefaultAccountPolicy allows five wrong passwords for an account
+	// before making it wait, and locks it for 15 minutes after fifteen.
+	DefaultAccountPolicy = Policy{
+		FreeAttempts: 5,
+		BaseDelay:    time.Second,
+		MaxDelay:     15 * time.Minute,
+		ForgetAfter:  24 * time.Hour,
+	}
+	// DefaultIPPolicy is looser, since many users can share an address.
+	DefaultIPPolicy = Policy{
+		FreeAttempts: 20,
+		BaseDelay:    time.Second,
+		MaxDelay:     15 * time.Minute,
+		ForgetAfter:  24 * time.Hour,
+	}
+)
+
+// Record is a key's run of failures.
+type Record struct {
+	Key          string
+	Failures     int32
+	LastFailedAt time.Time
+}
+
+// A Store keeps Records.
+type Store interface {
+	// Get returns the record for key, or a zero Record if it has none.
+	Get(ctx context.Context, key string) (Record, error)
+	// AddFailure counts a failure for key at at, starting its count again
+	// if its last failure was no later than forgetBefore, and returns its
+	// record.
+	AddFailure(ctx context.Context, key string, at, forgetBefore time.Time) (Record, error)
+	// Delete forgets key's failures.
+	Delete(ctx context.Context, key string) error
+}
+
+// Limiter tracks failed logins by account and by client IP.
+type Limiter struct {
+	Store   Store
+	Account Policy
+	IP      Policy
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a Limiter with the default policies.
+func New(store Store) *Limiter {
+	return &Limiter{
+		Store:   store,
+		Account: DefaultAccountPolicy,
+		IP:      DefaultIPPolicy,
+	}
+}
+
+func (l *Limiter) now() time.Time {
+	if l.Now == nil {
+		return time.Now()
+	}
+	return l.Now()
+}
+
+// AccountKey is the key failures for email are counted under.
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPKey is the key failures from ip are counted under. IPv6 addresses are
+// counted by their /64, since a client usually has a whole one.
+func IPKey(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "ip:" + ip
+	}
+	addr = addr.Unmap()
+	if addr.Is6() {
+		prefix, _ := addr.Prefix(64)
+		return "ip:" + prefix.String()
+	}
+	return "ip:" + addr.String()
+}
+
+// Check returns how long a login for email from ip must wait, or 0 if it
+// may go ahead.
+func (l *Limiter) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	var wait time.Duration
+	for _, k := range l.keys(email, ip) {
+		rec, err := l.Store.Get(ctx, k.key)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, l.wait(rec, k.policy))
+	}
+	return wait, nil
+}
+
+// Failed counts a failed login for email from ip, and returns how long the
+// next attempt must wait.
+func (l *Limiter) Failed(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := l.now()
+	var wait time.Duration
+	for _, k := range l.keys(email, ip) {
+		rec, err := l.Store.AddFailure(ctx, k.key, now, now.Add(-k.policy.ForgetAfter))
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, l.wait(rec, k.policy))
+	}
+	return wait, nil
+}
+
+// Succeeded forgets the failures for email after a successful login. The
+// IP's are kept, or one account could be used to reset them while guessing
+// the passwords of others.
+func (l *Limiter) Succeeded(ctx context.Context, email string) error {
+	return l.Store.Delete(ctx, AccountKey(email))
+}
+
+// Unlock forgets the failures counted under key, which is from AccountKey
+// or IPKey.
+func (l *Limiter) Unlock(ctx context.Context, key string) error {
+	return l.Store.Delete(ctx, key)
+}
+
+type policyKey struct {
+	key    string
+	policy Policy
+}
+
+func (l *Limiter) keys(email, ip string) []policyKey {
+	keys := []policyKey{{AccountKey(email), l.Account}}
+	if ip != "" {
+		keys = append(keys, policyKey{IPKey(ip), l.IP})
+	}
+	return keys
+}
+
+func (l *Limiter) wait(rec Record, p Policy) time.Duration {
+	if rec.Failures == 0 {
+		return 0
+	}
+	now := l.now()
+	if now.Sub(rec.LastFailedAt) >= p.ForgetAfter {
+		return 0
+	}
+	return max(rec.LastFailedAt.Add(p.Delay(rec.Failures)).Sub(now), 0)
+}
@@ -0,0 +1,100 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	p := DefaultAccountPolicy
+	tests := []struct {
+		failures int32
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 4, want: 0},
+		{failures: 5, want: time.Second},
+		{failures: 6, want: 2 * time.Second},
+		{failures: 14, want: 512 * time.Second},
+		{failures: 15, want: 15 * time.Minute},
+		{failures: 1000, want: 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestIPKey(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "203.0.113.7", want: "ip:203.0.113.7"},
+		{ip: "::ffff:203.0.113.7", want: "ip:203.0.113.7"},
+		{ip: "2001:db8:1:2:3:4:5:6", want: "ip:2001:db8:1:2::/64"},
+		{ip: "not an ip", want: "ip:not an ip"},
+	}
+	for _, tt := range tests {
+		if got := IPKey(tt.ip); got != tt.want {
+			t.Errorf("IPKey(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := New(NewMemoryStore())
+	l.Now = func() time.Time { return now }
+	l.Account = Policy{FreeAttempts: 2, BaseDelay: time.Minute, MaxDelay: 4 * time.Minute, ForgetAfter: time.Hour}
+	l.IP = Policy{FreeAttempts: 3, BaseDelay: time.Minute, MaxDelay: 4 * time.Minute, ForgetAfter: time.Hour}
+
+	check := func(email, ip string, want time.Duration) {
+		t.Helper()
+		got, err := l.Check(ctx, email, ip)
+		if err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("Check(%q, %q) = %v, want %v", email, ip, got, want)
+		}
+	}
+
+	l.Failed(ctx, "walt@example.com", "203.0.113.7")
+	check("walt@example.com", "203.0.113.7", 0)
+	l.Failed(ctx, "Walt@Example.com", "203.0.113.7")
+	check("walt@example.com", "198.51.100.1", time.Minute)
+	check("jesse@example.com", "203.0.113.7", 0)
+
+	// The IP's third failure makes it wait, whichever account it was for.
+	l.Failed(ctx, "jesse@example.com", "203.0.113.7")
+	check("gus@example.com", "203.0.113.7", time.Minute)
+
+	now = now.Add(time.Minute)
+	check("walt@example.com", "198.51.100.1", 0)
+	for range 3 {
+		l.Failed(ctx, "walt@example.com", "198.51.100.1")
+	}
+	check("walt@example.com", "198.51.100.1", 4*time.Minute)
+
+	// A success clears the account but not the IP.
+	l.Succeeded(ctx, "walt@example.com")
+	check("walt@example.com", "203.0.113.7", 0)
+	check("walt@example.com", "198.51.100.1", time.Minute)
+	l.Unlock(ctx, IPKey("198.51.100.1"))
+	check("walt@example.com", "198.51.100.1", 0)
+
+	// Failures are forgotten after ForgetAfter.
+	for range 3 {
+		l.Failed(ctx, "walt@example.com", "")
+	}
+	now = now.Add(time.Hour)
+	check("walt@example.com", "", 0)
+	wait, _ := l.Failed(ctx, "walt@example.com", "")
+	if wait != 0 {
+		t.Errorf("Failed() after ForgetAfter = %v, want 0", wait)
+	}
+}
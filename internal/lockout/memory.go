@@ -0,0 +1,59 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxMemoryRecords is how many records a MemoryStore holds before it drops
+// the ones that have been forgotten.
+const maxMemoryRecords = 10000
+
+// MemoryStore keeps records in memory, so they are lost on restart and not
+// shared between servers.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[key], nil
+}
+
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, at, forgetBefore time.Time) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) >= maxMemoryRecords {
+		for k, rec := range s.records {
+			if !rec.LastFailedAt.After(forgetBefore) {
+				delete(s.records, k)
+			}
+		}
+	}
+
+	rec, ok := s.records[key]
+	if !ok || !rec.LastFailedAt.After(forgetBefore) {
+		rec = Record{Key: key}
+	}
+	rec.Failures++
+	rec.LastFailedAt = at
+	s.records[key] = rec
+	return rec, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,144 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		msg     Message
+		want    string
+		wantErr error
+	}{
+		{
+			name: "Plain message",
+			msg:  Message{To: "walt@example.com", Subject: "Hi", Body: "one\ntwo"},
+			want: "From: chirpy@example.com\r\nTo: walt@example.com\r\nSubject: Hi\r\nDate: Wed, 01 May 2024 12:00:00 +0000\r\n" +
+				"MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\none\r\ntwo\r\n",
+		},
+		{
+			name:    "Header injection",
+			msg:     Message{To: "walt@example.com\r\nBcc: jesse@example.com", Subject: "Hi", Body: "hi"},
+			wantErr: errHeaderNewline,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := format("chirpy@example.com", tt.msg, date)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("format() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.txt")
+	m := &FileMailer{Path: path, From: "chirpy@example.com"}
+	for _, to := range []string{"walt@example.com", "jesse@example.com"} {
+		if err := m.Send(context.Background(), Message{To: to, Subject: "Hi", Body: "hello"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"To: walt@example.com\r\n", "To: jesse@example.com\r\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("file = %q, want it to contain %q", data, want)
+		}
+	}
+}
+
+// fakeSMTPServer answers one SMTP conversation on ln, returning what the
+// client sent after DATA. With silent set, it accepts the connection and
+// says nothing.
+func fakeSMTPServer(t *testing.T, ln net.Listener, silent bool) <-chan string {
+	t.Helper()
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if silent {
+			io.Copy(io.Discard, conn)
+			return
+		}
+		r := textproto.NewConn(conn)
+		r.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := r.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				r.PrintfLine("250 OK")
+			case "DATA":
+				r.PrintfLine("354 Go ahead")
+				data, _ := r.ReadDotBytes()
+				got <- string(data)
+				r.PrintfLine("250 OK")
+			case "QUIT":
+				r.PrintfLine("221 Bye")
+				return
+			default:
+				r.PrintfLine("502 Unknown")
+			}
+		}
+	}()
+	return got
+}
+
+func TestSMTPMailer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := fakeSMTPServer(t, ln, false)
+
+	m := &SMTPMailer{Addr: ln.Addr().String(), From: "chirpy@example.com"}
+	if err := m.Send(context.Background(), Message{To: "walt@example.com", Subject: "Hi", Body: "hello"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if data := <-got; !strings.Contains(data, "To: walt@example.com\n") || !strings.HasSuffix(data, "hello\n") {
+		t.Errorf("server got %q, want the message", data)
+	}
+}
+
+func TestSMTPMailerTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	fakeSMTPServer(t, ln, true)
+
+	m := &SMTPMailer{Addr: ln.Addr().String(), From: "chirpy@example.com", Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	if err := m.Send(context.Background(), Message{To: "walt@example.com", Subject: "Hi", Body: "hello"}); err == nil {
+		t.Fatalf("Send() to a silent server error = nil, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send() to a silent server took %s, want about 50ms", elapsed)
+	}
+}
@@ -0,0 +1,109 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) CreateApiKey(ctx context.Context, arg database.CreateApiKeyParams) (database.ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.ApiKey{}, foreignKeyViolation("api_keys", "api_keys_user_id_fkey")
+	}
+	for _, key := range s.apiKeys {
+		if key.KeyHash == arg.KeyHash {
+			return database.ApiKey{}, uniqueViolation("api_keys_key_hash_key")
+		}
+	}
+	key := database.ApiKey{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		Prefix:    arg.Prefix,
+		KeyHash:   arg.KeyHash,
+		Scope:     arg.Scope,
+		CreatedAt: s.now(),
+	}
+	s.apiKeys[key.ID] = key
+	return key, nil
+}
+
+func (s *Store) DeleteApiKey(ctx context.Context, arg database.DeleteApiKeyParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[arg.ID]
+	if !ok || key.UserID != arg.UserID {
+		return 0, nil
+	}
+	delete(s.apiKeys, arg.ID)
+	return 1, nil
+}
+
+func (s *Store) DeleteUserApiKeys(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, key := range s.apiKeys {
+		if key.UserID == userID {
+			delete(s.apiKeys, id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetApiKeyByHash(ctx context.Context, keyHash string) (database.ApiKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.apiKeys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return database.ApiKey{}, sql.ErrNoRows
+}
+
+func (s *Store) ListApiKeys(ctx context.Context, userID uuid.UUID) ([]database.ApiKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []database.ApiKey
+	for _, key := range s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return bytes.Compare(keys[i].ID[:], keys[j].ID[:]) > 0
+	})
+	return keys, nil
+}
+
+func (s *Store) TouchApiKey(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return nil
+	}
+	now := s.now()
+	if key.LastUsedAt.Valid && !key.LastUsedAt.Time.Before(now.Add(-time.Minute)) {
+		return nil
+	}
+	key.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+	s.apiKeys[id] = key
+	return nil
+}
@@ -0,0 +1,38 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) AddBannedWord(ctx context.Context, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.bannedWords[word]; !ok {
+		s.bannedWords[word] = database.BannedWord{Word: word, CreatedAt: s.now()}
+	}
+	return nil
+}
+
+func (s *Store) ListBannedWords(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []string
+	for word := range s.bannedWords {
+		items = append(items, word)
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+func (s *Store) RemoveBannedWord(ctx context.Context, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.bannedWords, word)
+	return nil
+}
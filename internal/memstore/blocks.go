@@ -0,0 +1,95 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type blockKey struct {
+	blocker uuid.UUID
+	blocked uuid.UUID
+}
+
+func (s *Store) BlockUser(ctx context.Context, arg database.BlockUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if arg.BlockerID == arg.BlockedID {
+		return &pq.Error{
+			Code:       "23514",
+			Message:    `new row for relation "blocks" violates check constraint "blocks_check"`,
+			Constraint: "blocks_check",
+		}
+	}
+	if _, ok := s.users[arg.BlockerID]; !ok {
+		return foreignKeyViolation("blocks", "blocks_blocker_id_fkey")
+	}
+	if _, ok := s.users[arg.BlockedID]; !ok {
+		return foreignKeyViolation("blocks", "blocks_blocked_id_fkey")
+	}
+
+	key := blockKey{blocker: arg.BlockerID, blocked: arg.BlockedID}
+	if _, ok := s.blocks[key]; !ok {
+		s.blocks[key] = database.Block{
+			BlockerID: arg.BlockerID,
+			BlockedID: arg.BlockedID,
+			CreatedAt: s.now(),
+		}
+	}
+	return nil
+}
+
+func (s *Store) UnblockUser(ctx context.Context, arg database.UnblockUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blocks, blockKey{blocker: arg.BlockerID, blocked: arg.BlockedID})
+	return nil
+}
+
+func (s *Store) IsBlocked(ctx context.Context, arg database.IsBlockedParams) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.blocks[blockKey{blocker: arg.BlockerID, blocked: arg.BlockedID}]
+	return ok, nil
+}
+
+func (s *Store) DeleteBlockedRechirps(ctx context.Context, arg database.DeleteBlockedRechirpsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, c := range s.chirps {
+		if c.Kind == "rechirp" && c.UserID == arg.BlockedID && s.authoredBy(c.RefChirpID, arg.BlockerID) {
+			s.deleteChirp(id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DetachBlockedQuotes(ctx context.Context, arg database.DetachBlockedQuotesParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, c := range s.chirps {
+		if c.Kind == "quote" && c.UserID == arg.BlockedID && s.authoredBy(c.RefChirpID, arg.BlockerID) {
+			c.RefChirpID = uuid.NullUUID{}
+			c.UpdatedAt = s.now()
+			s.chirps[id] = c
+		}
+	}
+	return nil
+}
+
+// authoredBy reports whether chirpID refers to a chirp written by userID. The
+// caller must hold s.mu.
+func (s *Store) authoredBy(chirpID uuid.NullUUID, userID uuid.UUID) bool {
+	if !chirpID.Valid {
+		return false
+	}
+	chirp, ok := s.chirps[chirpID.UUID]
+	return ok && chirp.UserID == userID
+}
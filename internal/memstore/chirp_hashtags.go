@@ -0,0 +1,86 @@
+package memstore
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type hashtagKey struct {
+	chirp uuid.UUID
+	tag   string
+}
+
+func (s *Store) AddChirpHashtags(ctx context.Context, arg database.AddChirpHashtagsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chirp, ok := s.chirps[arg.ChirpID]
+	if !ok {
+		return nil
+	}
+	for _, tag := range arg.Tags {
+		key := hashtagKey{chirp: arg.ChirpID, tag: tag}
+		if _, ok := s.hashtags[key]; ok {
+			continue
+		}
+		s.hashtags[key] = database.ChirpHashtag{
+			ChirpID:   arg.ChirpID,
+			Tag:       tag,
+			CreatedAt: chirp.CreatedAt,
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetTrendingHashtags(ctx context.Context, arg database.GetTrendingHashtagsParams) ([]database.GetTrendingHashtagsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	stats := map[string]*database.GetTrendingHashtagsRow{}
+	for _, h := range s.hashtags {
+		if h.CreatedAt.Before(arg.Since) {
+			continue
+		}
+		row, ok := stats[h.Tag]
+		if !ok {
+			row = &database.GetTrendingHashtagsRow{Tag: h.Tag}
+			stats[h.Tag] = row
+		}
+		row.ChirpCount++
+		row.Score += math.Pow(0.5, now.Sub(h.CreatedAt).Seconds()/arg.HalfLifeSeconds)
+	}
+
+	var items []database.GetTrendingHashtagsRow
+	for _, row := range stats {
+		items = append(items, *row)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].Tag < items[j].Tag
+	})
+	if len(items) > int(arg.Limit) {
+		items = items[:arg.Limit]
+	}
+	return items, nil
+}
+
+func (s *Store) ListChirpsByHashtag(ctx context.Context, arg database.ListChirpsByHashtagParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.filterChirps(func(c database.Chirp) bool {
+		if _, ok := s.hashtags[hashtagKey{chirp: c.ID, tag: arg.Tag}]; !ok {
+			return false
+		}
+		return !arg.CursorCreatedAt.Valid || compareChirpKey(c, arg.CursorCreatedAt.Time, arg.CursorID.UUID) < 0
+	})
+	reverseChirps(items)
+	return limitChirps(items, arg.Limit), nil
+}
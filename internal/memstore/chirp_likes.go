@@ -0,0 +1,76 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type likeKey struct {
+	chirp uuid.UUID
+	user  uuid.UUID
+}
+
+func (s *Store) LikeChirp(ctx context.Context, arg database.LikeChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return 0, foreignKeyViolation("chirp_likes", "chirp_likes_chirp_id_fkey")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return 0, foreignKeyViolation("chirp_likes", "chirp_likes_user_id_fkey")
+	}
+
+	key := likeKey{chirp: arg.ChirpID, user: arg.UserID}
+	if _, ok := s.likes[key]; ok {
+		return 0, nil
+	}
+	s.likes[key] = database.ChirpLike{
+		ChirpID:   arg.ChirpID,
+		UserID:    arg.UserID,
+		CreatedAt: s.now(),
+	}
+	return 1, nil
+}
+
+func (s *Store) UnlikeChirp(ctx context.Context, arg database.UnlikeChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.likes, likeKey{chirp: arg.ChirpID, user: arg.UserID})
+	return nil
+}
+
+func (s *Store) GetChirpLikeStats(ctx context.Context, arg database.GetChirpLikeStatsParams) ([]database.GetChirpLikeStatsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[uuid.UUID]bool, len(arg.ChirpIds))
+	for _, id := range arg.ChirpIds {
+		wanted[id] = true
+	}
+
+	stats := map[uuid.UUID]*database.GetChirpLikeStatsRow{}
+	for key := range s.likes {
+		if !wanted[key.chirp] {
+			continue
+		}
+		row, ok := stats[key.chirp]
+		if !ok {
+			row = &database.GetChirpLikeStatsRow{ChirpID: key.chirp}
+			stats[key.chirp] = row
+		}
+		row.LikeCount++
+		if arg.ViewerID.Valid && key.user == arg.ViewerID.UUID {
+			row.LikedByMe = true
+		}
+	}
+
+	var items []database.GetChirpLikeStatsRow
+	for _, row := range stats {
+		items = append(items, *row)
+	}
+	return items, nil
+}
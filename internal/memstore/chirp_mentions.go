@@ -0,0 +1,63 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type mentionKey struct {
+	chirp uuid.UUID
+	start int32
+}
+
+func (s *Store) AddChirpMentions(ctx context.Context, arg database.AddChirpMentionsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return foreignKeyViolation("chirp_mentions", "chirp_mentions_chirp_id_fkey")
+	}
+	for i, userID := range arg.UserIds {
+		if _, ok := s.users[userID]; !ok {
+			return foreignKeyViolation("chirp_mentions", "chirp_mentions_user_id_fkey")
+		}
+		key := mentionKey{chirp: arg.ChirpID, start: arg.StartOffsets[i]}
+		if _, ok := s.mentions[key]; ok {
+			continue
+		}
+		s.mentions[key] = database.ChirpMention{
+			ChirpID:     arg.ChirpID,
+			UserID:      userID,
+			StartOffset: arg.StartOffsets[i],
+			EndOffset:   arg.EndOffsets[i],
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetChirpMentions(ctx context.Context, chirpIds []uuid.UUID) ([]database.ChirpMention, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+
+	var items []database.ChirpMention
+	for key, m := range s.mentions {
+		if wanted[key.chirp] {
+			items = append(items, m)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ChirpID != items[j].ChirpID {
+			return items[i].ChirpID.String() < items[j].ChirpID.String()
+		}
+		return items[i].StartOffset < items[j].StartOffset
+	})
+	return items, nil
+}
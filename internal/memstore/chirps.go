@@ -0,0 +1,311 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, foreignKeyViolation("chirps", "chirps_user_id_fkey")
+	}
+	if arg.InReplyTo.Valid {
+		if _, ok := s.chirps[arg.InReplyTo.UUID]; !ok {
+			return database.Chirp{}, foreignKeyViolation("chirps", "chirps_in_reply_to_fkey")
+		}
+	}
+	if arg.RefChirpID.Valid {
+		if _, ok := s.chirps[arg.RefChirpID.UUID]; !ok {
+			return database.Chirp{}, foreignKeyViolation("chirps", "chirps_ref_chirp_id_fkey")
+		}
+	}
+	switch arg.Kind {
+	case "chirp", "quote":
+	case "rechirp":
+		if _, ok := s.findRechirp(arg.UserID, arg.RefChirpID); ok {
+			return database.Chirp{}, uniqueViolation("chirps_user_id_rechirp_idx")
+		}
+	default:
+		return database.Chirp{}, &pq.Error{
+			Code:       "23514",
+			Message:    `new row for relation "chirps" violates check constraint "chirps_kind_check"`,
+			Constraint: "chirps_kind_check",
+		}
+	}
+
+	now := s.now()
+	chirp := database.Chirp{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Body:       arg.Body,
+		UserID:     arg.UserID,
+		InReplyTo:  arg.InReplyTo,
+		Kind:       arg.Kind,
+		RefChirpID: arg.RefChirpID,
+	}
+	s.chirps[chirp.ID] = chirp
+	return chirp, nil
+}
+
+func (s *Store) DeleteChirpById(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for rechirpID, c := range s.chirps {
+		if c.Kind == "rechirp" && c.RefChirpID.Valid && c.RefChirpID.UUID == id {
+			s.deleteChirp(rechirpID)
+		}
+	}
+	s.deleteChirp(id)
+	return nil
+}
+
+// deleteChirp removes a chirp with its likes, hashtags, mentions, flag and
+// notifications and detaches the chirps that reply to or repost it, as the
+// chirps' foreign keys do. The caller must hold s.mu.
+func (s *Store) deleteChirp(id uuid.UUID) {
+	delete(s.chirps, id)
+	for key := range s.likes {
+		if key.chirp == id {
+			delete(s.likes, key)
+		}
+	}
+	for key := range s.hashtags {
+		if key.chirp == id {
+			delete(s.hashtags, key)
+		}
+	}
+	delete(s.flags, id)
+	for key := range s.mentions {
+		if key.chirp == id {
+			delete(s.mentions, key)
+		}
+	}
+	for nid, n := range s.notifications {
+		if n.ChirpID.Valid && n.ChirpID.UUID == id {
+			delete(s.notifications, nid)
+		}
+	}
+	for otherID, other := range s.chirps {
+		changed := false
+		if other.InReplyTo.Valid && other.InReplyTo.UUID == id {
+			other.InReplyTo = uuid.NullUUID{}
+			changed = true
+		}
+		if other.RefChirpID.Valid && other.RefChirpID.UUID == id {
+			other.RefChirpID = uuid.NullUUID{}
+			changed = true
+		}
+		if changed {
+			s.chirps[otherID] = other
+		}
+	}
+}
+
+func (s *Store) DeleteRechirp(ctx context.Context, arg database.DeleteRechirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rechirp, ok := s.findRechirp(arg.UserID, arg.RefChirpID); ok {
+		s.deleteChirp(rechirp.ID)
+	}
+	return nil
+}
+
+func (s *Store) GetRechirp(ctx context.Context, arg database.GetRechirpParams) (database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rechirp, ok := s.findRechirp(arg.UserID, arg.RefChirpID)
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return rechirp, nil
+}
+
+// findRechirp returns userID's rechirp of ref. The caller must hold s.mu.
+func (s *Store) findRechirp(userID uuid.UUID, ref uuid.NullUUID) (database.Chirp, bool) {
+	if !ref.Valid {
+		return database.Chirp{}, false
+	}
+	for _, c := range s.chirps {
+		if c.Kind == "rechirp" && c.UserID == userID && c.RefChirpID == ref {
+			return c, true
+		}
+	}
+	return database.Chirp{}, false
+}
+
+func (s *Store) GetRechirpCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetRechirpCountsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+	counts := map[uuid.UUID]int64{}
+	for _, c := range s.chirps {
+		if c.Kind == "rechirp" && c.RefChirpID.Valid && wanted[c.RefChirpID.UUID] {
+			counts[c.RefChirpID.UUID]++
+		}
+	}
+
+	var items []database.GetRechirpCountsRow
+	for id, n := range counts {
+		items = append(items, database.GetRechirpCountsRow{
+			RefChirpID:   uuid.NullUUID{UUID: id, Valid: true},
+			RechirpCount: n,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) GetChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []database.Chirp
+	for _, id := range ids {
+		if chirp, ok := s.chirps[id]; ok {
+			items = append(items, chirp)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []database.Chirp
+	chirp, ok := s.chirps[id]
+	for ok && chirp.InReplyTo.Valid {
+		chirp, ok = s.chirps[chirp.InReplyTo.UUID]
+		if ok {
+			items = append(items, chirp)
+		}
+	}
+	reverseChirps(items)
+	return items, nil
+}
+
+func (s *Store) GetChirpDescendants(ctx context.Context, arg database.GetChirpDescendantsParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []database.Chirp
+	parents := map[uuid.UUID]bool{arg.ChirpID: true}
+	for depth := int32(1); len(parents) > 0 && depth <= arg.MaxDepth; depth++ {
+		next := map[uuid.UUID]bool{}
+		for id, chirp := range s.chirps {
+			if chirp.InReplyTo.Valid && parents[chirp.InReplyTo.UUID] {
+				items = append(items, chirp)
+				next[id] = true
+			}
+		}
+		parents = next
+	}
+	sortChirps(items)
+	return limitChirps(items, arg.Limit), nil
+}
+
+func (s *Store) GetChirpById(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chirp, ok := s.chirps[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+func (s *Store) ListChirpsAsc(ctx context.Context, arg database.ListChirpsAscParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.filterChirps(func(c database.Chirp) bool {
+		return (!arg.AuthorID.Valid || c.UserID == arg.AuthorID.UUID) &&
+			(!arg.CursorCreatedAt.Valid || compareChirpKey(c, arg.CursorCreatedAt.Time, arg.CursorID.UUID) > 0)
+	})
+	return limitChirps(items, arg.Limit), nil
+}
+
+func (s *Store) ListChirpsDesc(ctx context.Context, arg database.ListChirpsDescParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.filterChirps(func(c database.Chirp) bool {
+		return (!arg.AuthorID.Valid || c.UserID == arg.AuthorID.UUID) &&
+			(!arg.CursorCreatedAt.Valid || compareChirpKey(c, arg.CursorCreatedAt.Time, arg.CursorID.UUID) < 0)
+	})
+	reverseChirps(items)
+	return limitChirps(items, arg.Limit), nil
+}
+
+func (s *Store) ListReplies(ctx context.Context, arg database.ListRepliesParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.filterChirps(func(c database.Chirp) bool {
+		return c.InReplyTo.Valid && c.InReplyTo.UUID == arg.ChirpID &&
+			(!arg.CursorCreatedAt.Valid || compareChirpKey(c, arg.CursorCreatedAt.Time, arg.CursorID.UUID) > 0)
+	})
+	return limitChirps(items, arg.Limit), nil
+}
+
+// filterChirps returns the chirps matching keep ordered by created_at, or nil
+// if there are none, as sqlc's :many queries do. The caller must hold s.mu.
+func (s *Store) filterChirps(keep func(database.Chirp) bool) []database.Chirp {
+	var items []database.Chirp
+	for _, chirp := range s.chirps {
+		if keep(chirp) {
+			items = append(items, chirp)
+		}
+	}
+	sortChirps(items)
+	return items
+}
+
+// sortChirps orders chirps by (created_at, id), the key the pagination
+// queries use.
+func sortChirps(chirps []database.Chirp) {
+	sort.Slice(chirps, func(i, j int) bool {
+		return compareChirpKey(chirps[i], chirps[j].CreatedAt, chirps[j].ID) < 0
+	})
+}
+
+// compareChirpKey compares the row (c.created_at, c.id) with (createdAt, id)
+// the way Postgres compares row values.
+func compareChirpKey(c database.Chirp, createdAt time.Time, id uuid.UUID) int {
+	if c := c.CreatedAt.Compare(createdAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(c.ID[:], id[:])
+}
+
+func reverseChirps(chirps []database.Chirp) {
+	for i, j := 0, len(chirps)-1; i < j; i, j = i+1, j-1 {
+		chirps[i], chirps[j] = chirps[j], chirps[i]
+	}
+}
+
+// limitChirps applies a LIMIT clause.
+func limitChirps(chirps []database.Chirp, limit int32) []database.Chirp {
+	if limit >= 0 && len(chirps) > int(limit) {
+		return chirps[:limit]
+	}
+	return chirps
+}
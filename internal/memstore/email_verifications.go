@@ -0,0 +1,53 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) CreateEmailVerification(ctx context.Context, arg database.CreateEmailVerificationParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyViolation("email_verifications", "email_verifications_user_id_fkey")
+	}
+	if _, ok := s.verifications[arg.TokenHash]; ok {
+		return uniqueViolation("email_verifications_pkey")
+	}
+	s.verifications[arg.TokenHash] = database.EmailVerification{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		Email:     arg.Email,
+		CreatedAt: s.now(),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (s *Store) DeleteEmailVerifications(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for tokenHash, v := range s.verifications {
+		if v.UserID == userID {
+			delete(s.verifications, tokenHash)
+		}
+	}
+	return nil
+}
+
+func (s *Store) UseEmailVerification(ctx context.Context, arg database.UseEmailVerificationParams) (database.EmailVerification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.verifications[arg.TokenHash]
+	if !ok || !v.ExpiresAt.After(arg.Now) {
+		return database.EmailVerification{}, sql.ErrNoRows
+	}
+	delete(s.verifications, arg.TokenHash)
+	return v, nil
+}
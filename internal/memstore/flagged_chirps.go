@@ -0,0 +1,71 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) FlagChirp(ctx context.Context, arg database.FlagChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return foreignKeyViolation("flagged_chirps", "flagged_chirps_chirp_id_fkey")
+	}
+	flag, ok := s.flags[arg.ChirpID]
+	if !ok {
+		flag = database.FlaggedChirp{ChirpID: arg.ChirpID, CreatedAt: s.now()}
+	}
+	flag.Words = append([]string(nil), arg.Words...)
+	s.flags[arg.ChirpID] = flag
+	return nil
+}
+
+func (s *Store) ListFlaggedChirps(ctx context.Context, arg database.ListFlaggedChirpsParams) ([]database.ListFlaggedChirpsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// before orders flags by (created_at, chirp_id) descending.
+	before := func(a database.FlaggedChirp, createdAt time.Time, id uuid.UUID) bool {
+		if c := a.CreatedAt.Compare(createdAt); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(a.ChirpID[:], id[:]) > 0
+	}
+
+	var flags []database.FlaggedChirp
+	for _, flag := range s.flags {
+		if !arg.CursorCreatedAt.Valid || !before(flag, arg.CursorCreatedAt.Time, arg.CursorID.UUID) {
+			flags = append(flags, flag)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return before(flags[i], flags[j].CreatedAt, flags[j].ChirpID)
+	})
+
+	var items []database.ListFlaggedChirpsRow
+	for _, flag := range flags {
+		if len(items) == int(arg.Limit) {
+			break
+		}
+		items = append(items, database.ListFlaggedChirpsRow{
+			Chirp:     s.chirps[flag.ChirpID],
+			Words:     flag.Words,
+			FlaggedAt: flag.CreatedAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) UnflagChirp(ctx context.Context, chirpID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.flags, chirpID)
+	return nil
+}
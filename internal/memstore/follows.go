@@ -0,0 +1,125 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type followKey struct {
+	follower uuid.UUID
+	followee uuid.UUID
+}
+
+func (s *Store) FollowUser(ctx context.Context, arg database.FollowUserParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if arg.FollowerID == arg.FolloweeID {
+		return 0, &pq.Error{
+			Code:       "23514",
+			Message:    `new row for relation "follows" violates check constraint "follows_check"`,
+			Constraint: "follows_check",
+		}
+	}
+	if _, ok := s.users[arg.FollowerID]; !ok {
+		return 0, foreignKeyViolation("follows", "follows_follower_id_fkey")
+	}
+	if _, ok := s.users[arg.FolloweeID]; !ok {
+		return 0, foreignKeyViolation("follows", "follows_followee_id_fkey")
+	}
+
+	key := followKey{follower: arg.FollowerID, followee: arg.FolloweeID}
+	if _, ok := s.follows[key]; ok {
+		return 0, nil
+	}
+	s.follows[key] = database.Follow{
+		FollowerID: arg.FollowerID,
+		FolloweeID: arg.FolloweeID,
+		CreatedAt:  s.now(),
+	}
+	return 1, nil
+}
+
+func (s *Store) UnfollowUser(ctx context.Context, arg database.UnfollowUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.follows, followKey{follower: arg.FollowerID, followee: arg.FolloweeID})
+	return nil
+}
+
+func (s *Store) ListFollowers(ctx context.Context, arg database.ListFollowersParams) ([]database.ListFollowersRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []database.ListFollowersRow
+	for _, row := range s.listFollows(arg.UserID, arg.CursorCreatedAt.Time, arg.CursorID.UUID, arg.CursorCreatedAt.Valid, arg.Limit, true) {
+		items = append(items, database.ListFollowersRow(row))
+	}
+	return items, nil
+}
+
+func (s *Store) ListFollowing(ctx context.Context, arg database.ListFollowingParams) ([]database.ListFollowingRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []database.ListFollowingRow
+	for _, row := range s.listFollows(arg.UserID, arg.CursorCreatedAt.Time, arg.CursorID.UUID, arg.CursorCreatedAt.Valid, arg.Limit, false) {
+		items = append(items, database.ListFollowingRow(row))
+	}
+	return items, nil
+}
+
+// listFollows returns the users on the other side of userID's follows, newest
+// follow first. followers selects who follows userID rather than who userID
+// follows. The caller must hold s.mu.
+func (s *Store) listFollows(userID uuid.UUID, cursorAt time.Time, cursorID uuid.UUID, hasCursor bool, limit int32, followers bool) []database.ListFollowersRow {
+	var items []database.ListFollowersRow
+	for key, follow := range s.follows {
+		other := key.followee
+		if followers {
+			other = key.follower
+		}
+		if (followers && key.followee != userID) || (!followers && key.follower != userID) {
+			continue
+		}
+		if hasCursor {
+			if c := follow.CreatedAt.Compare(cursorAt); c > 0 || (c == 0 && bytes.Compare(other[:], cursorID[:]) >= 0) {
+				continue
+			}
+		}
+		items = append(items, database.ListFollowersRow{User: s.users[other], FollowedAt: follow.CreatedAt})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if c := items[i].FollowedAt.Compare(items[j].FollowedAt); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(items[i].User.ID[:], items[j].User.ID[:]) > 0
+	})
+	if limit >= 0 && len(items) > int(limit) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func (s *Store) GetTimeline(ctx context.Context, arg database.GetTimelineParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.filterChirps(func(c database.Chirp) bool {
+		if c.UserID != arg.UserID {
+			if _, ok := s.follows[followKey{follower: arg.UserID, followee: c.UserID}]; !ok {
+				return false
+			}
+		}
+		return !arg.CursorCreatedAt.Valid || compareChirpKey(c, arg.CursorCreatedAt.Time, arg.CursorID.UUID) < 0
+	})
+	reverseChirps(items)
+	return limitChirps(items, arg.Limit), nil
+}
@@ -0,0 +1,54 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) DeleteLoginFailures(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.loginFailures, key)
+	return nil
+}
+
+func (s *Store) DeleteStaleLoginFailures(ctx context.Context, lastFailedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, f := range s.loginFailures {
+		if !f.LastFailedAt.After(lastFailedAt) {
+			delete(s.loginFailures, key)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetLoginFailures(ctx context.Context, key string) (database.LoginFailure, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.loginFailures[key]
+	if !ok {
+		return database.LoginFailure{}, sql.ErrNoRows
+	}
+	return f, nil
+}
+
+func (s *Store) RecordLoginFailure(ctx context.Context, arg database.RecordLoginFailureParams) (database.LoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.loginFailures[arg.Key]
+	if !ok || !f.LastFailedAt.After(arg.ForgetBefore) {
+		f = database.LoginFailure{Key: arg.Key}
+	}
+	f.Failures++
+	f.LastFailedAt = arg.At
+	s.loginFailures[arg.Key] = f
+	return f, nil
+}
@@ -0,0 +1,191 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+type recoveryCodeKey struct {
+	user     uuid.UUID
+	codeHash string
+}
+
+func (s *Store) AdvanceUserTotpStep(ctx context.Context, arg database.AdvanceUserTotpStepParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totp, ok := s.userTotp[arg.UserID]
+	if !ok || !totp.ConfirmedAt.Valid || totp.LastUsedStep >= arg.Step {
+		return 0, nil
+	}
+	totp.LastUsedStep = arg.Step
+	s.userTotp[arg.UserID] = totp
+	return 1, nil
+}
+
+func (s *Store) AttemptMfaChallenge(ctx context.Context, arg database.AttemptMfaChallengeParams) (database.MfaChallenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.mfaChallenges[arg.TokenHash]
+	if !ok || !c.ExpiresAt.After(arg.Now) || c.Attempts >= arg.MaxAttempts {
+		return database.MfaChallenge{}, sql.ErrNoRows
+	}
+	c.Attempts++
+	s.mfaChallenges[arg.TokenHash] = c
+	return c, nil
+}
+
+func (s *Store) ConfirmUserTotp(ctx context.Context, arg database.ConfirmUserTotpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totp, ok := s.userTotp[arg.UserID]
+	if !ok || totp.ConfirmedAt.Valid {
+		return 0, nil
+	}
+	totp.ConfirmedAt = sql.NullTime{Time: s.now(), Valid: true}
+	totp.LastUsedStep = arg.Step
+	s.userTotp[arg.UserID] = totp
+	return 1, nil
+}
+
+func (s *Store) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int64
+	for key, code := range s.recoveryCodes {
+		if key.user == userID && !code.UsedAt.Valid {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) CreateMfaChallenge(ctx context.Context, arg database.CreateMfaChallengeParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyViolation("mfa_challenges", "mfa_challenges_user_id_fkey")
+	}
+	if _, ok := s.mfaChallenges[arg.TokenHash]; ok {
+		return uniqueViolation("mfa_challenges_pkey")
+	}
+	s.mfaChallenges[arg.TokenHash] = database.MfaChallenge{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		CreatedAt: s.now(),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (s *Store) CreateRecoveryCodes(ctx context.Context, arg database.CreateRecoveryCodesParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyViolation("recovery_codes", "recovery_codes_user_id_fkey")
+	}
+	for _, codeHash := range arg.CodeHashes {
+		if _, ok := s.recoveryCodes[recoveryCodeKey{arg.UserID, codeHash}]; ok {
+			return uniqueViolation("recovery_codes_pkey")
+		}
+	}
+	for _, codeHash := range arg.CodeHashes {
+		s.recoveryCodes[recoveryCodeKey{arg.UserID, codeHash}] = database.RecoveryCode{
+			UserID:    arg.UserID,
+			CodeHash:  codeHash,
+			CreatedAt: s.now(),
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteMfaChallenge(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.mfaChallenges, tokenHash)
+	return nil
+}
+
+func (s *Store) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.recoveryCodes {
+		if key.user == userID {
+			delete(s.recoveryCodes, key)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteUserMfaChallenges(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for tokenHash, challenge := range s.mfaChallenges {
+		if challenge.UserID == userID {
+			delete(s.mfaChallenges, tokenHash)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteUserTotp(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.userTotp, userID)
+	return nil
+}
+
+func (s *Store) GetUserTotp(ctx context.Context, userID uuid.UUID) (database.UserTotp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	totp, ok := s.userTotp[userID]
+	if !ok {
+		return database.UserTotp{}, sql.ErrNoRows
+	}
+	return totp, nil
+}
+
+func (s *Store) SetPendingUserTotp(ctx context.Context, arg database.SetPendingUserTotpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return 0, foreignKeyViolation("user_totp", "user_totp_user_id_fkey")
+	}
+	if totp, ok := s.userTotp[arg.UserID]; ok && totp.ConfirmedAt.Valid {
+		return 0, nil
+	}
+	s.userTotp[arg.UserID] = database.UserTotp{
+		UserID:    arg.UserID,
+		Secret:    arg.Secret,
+		CreatedAt: s.now(),
+	}
+	return 1, nil
+}
+
+func (s *Store) UseRecoveryCode(ctx context.Context, arg database.UseRecoveryCodeParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := recoveryCodeKey{arg.UserID, arg.CodeHash}
+	code, ok := s.recoveryCodes[key]
+	if !ok || code.UsedAt.Valid {
+		return 0, nil
+	}
+	code.UsedAt = sql.NullTime{Time: s.now(), Valid: true}
+	s.recoveryCodes[key] = code
+	return 1, nil
+}
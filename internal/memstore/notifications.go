@@ -0,0 +1,115 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyViolation("notifications", "notifications_user_id_fkey")
+	}
+	if _, ok := s.users[arg.ActorID]; !ok {
+		return foreignKeyViolation("notifications", "notifications_actor_id_fkey")
+	}
+	if arg.ChirpID.Valid {
+		if _, ok := s.chirps[arg.ChirpID.UUID]; !ok {
+			return foreignKeyViolation("notifications", "notifications_chirp_id_fkey")
+		}
+	}
+	switch arg.Kind {
+	case "mention", "reply", "like", "follow":
+	default:
+		return &pq.Error{
+			Code:       "23514",
+			Message:    `new row for relation "notifications" violates check constraint "notifications_kind_check"`,
+			Constraint: "notifications_kind_check",
+		}
+	}
+
+	n := database.Notification{
+		ID:        uuid.New(),
+		CreatedAt: s.now(),
+		UserID:    arg.UserID,
+		ActorID:   arg.ActorID,
+		Kind:      arg.Kind,
+		ChirpID:   arg.ChirpID,
+	}
+	s.notifications[n.ID] = n
+	return nil
+}
+
+func (s *Store) ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.ListNotificationsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []database.ListNotificationsRow
+	for _, n := range s.notifications {
+		if n.UserID != arg.UserID || (arg.UnreadOnly && n.ReadAt.Valid) {
+			continue
+		}
+		if arg.CursorCreatedAt.Valid && compareNotificationKey(n, arg.CursorCreatedAt.Time, arg.CursorID.UUID) >= 0 {
+			continue
+		}
+		items = append(items, database.ListNotificationsRow{
+			Notification: n,
+			ActorHandle:  s.users[n.ActorID].Handle,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i].Notification, items[j].Notification
+		return compareNotificationKey(a, b.CreatedAt, b.ID) > 0
+	})
+	if len(items) > int(arg.Limit) {
+		items = items[:arg.Limit]
+	}
+	return items, nil
+}
+
+func (s *Store) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, n := range s.notifications {
+		if n.UserID == userID && !n.ReadAt.Valid {
+			n.ReadAt = sql.NullTime{Time: now, Valid: true}
+			s.notifications[id] = n
+		}
+	}
+	return nil
+}
+
+func (s *Store) MarkNotificationsRead(ctx context.Context, arg database.MarkNotificationsReadParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, id := range arg.Ids {
+		n, ok := s.notifications[id]
+		if ok && n.UserID == arg.UserID && !n.ReadAt.Valid {
+			n.ReadAt = sql.NullTime{Time: now, Valid: true}
+			s.notifications[id] = n
+		}
+	}
+	return nil
+}
+
+// compareNotificationKey orders notifications by (created_at, id), the key
+// ListNotifications pages on.
+func compareNotificationKey(n database.Notification, createdAt time.Time, id uuid.UUID) int {
+	if c := n.CreatedAt.Compare(createdAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(n.ID[:], id[:])
+}
@@ -0,0 +1,52 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) CreatePasswordReset(ctx context.Context, arg database.CreatePasswordResetParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyViolation("password_resets", "password_resets_user_id_fkey")
+	}
+	if _, ok := s.resets[arg.TokenHash]; ok {
+		return uniqueViolation("password_resets_pkey")
+	}
+	s.resets[arg.TokenHash] = database.PasswordReset{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		CreatedAt: s.now(),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (s *Store) DeletePasswordResets(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for tokenHash, reset := range s.resets {
+		if reset.UserID == userID {
+			delete(s.resets, tokenHash)
+		}
+	}
+	return nil
+}
+
+func (s *Store) UsePasswordReset(ctx context.Context, arg database.UsePasswordResetParams) (database.PasswordReset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reset, ok := s.resets[arg.TokenHash]
+	if !ok || !reset.ExpiresAt.After(arg.Now) {
+		return database.PasswordReset{}, sql.ErrNoRows
+	}
+	delete(s.resets, arg.TokenHash)
+	return reset, nil
+}
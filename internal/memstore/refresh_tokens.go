@@ -0,0 +1,206 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) ConsumeRefreshToken(ctx context.Context, tokenHash string) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rt, ok := s.refreshTokens[tokenHash]
+	if !ok || rt.RevokedAt.Valid || !rt.ExpiresAt.After(now) {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	rt.UpdatedAt = now
+	s.refreshTokens[tokenHash] = rt
+	return rt, nil
+}
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.refreshTokens[arg.TokenHash]; ok {
+		return database.RefreshToken{}, uniqueViolation("refresh_tokens_pkey")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.RefreshToken{}, foreignKeyViolation("refresh_tokens", "refresh_tokens_user_id_fkey")
+	}
+
+	now := s.now()
+	rt := database.RefreshToken{
+		TokenHash:       arg.TokenHash,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		UserID:          arg.UserID,
+		ExpiresAt:       arg.ExpiresAt,
+		FamilyID:        arg.FamilyID,
+		ParentTokenHash: arg.ParentTokenHash,
+		UserAgent:       arg.UserAgent,
+		IpAddress:       arg.IpAddress,
+		LastUsedAt:      now,
+	}
+	s.refreshTokens[rt.TokenHash] = rt
+	return rt, nil
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, tokenHash string) (database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rt, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return rt, nil
+}
+
+func (s *Store) GetRefreshTokenReplacement(ctx context.Context, tokenHash string) (database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	parent, ok := s.refreshTokens[tokenHash]
+	if !ok || !parent.RevokedAt.Valid || !parent.RevokedAt.Time.After(now.Add(-10*time.Second)) {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	for _, rt := range s.refreshTokens {
+		if rt.ParentTokenHash.Valid && rt.ParentTokenHash.String == tokenHash && !rt.RevokedAt.Valid && rt.ExpiresAt.After(now) {
+			return rt, nil
+		}
+	}
+	return database.RefreshToken{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserFromRefreshToken(ctx context.Context, tokenHash string) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rt, ok := s.refreshTokens[tokenHash]
+	if !ok || rt.RevokedAt.Valid || !rt.ExpiresAt.After(s.now()) {
+		return database.User{}, sql.ErrNoRows
+	}
+	user, ok := s.users[rt.UserID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *Store) ListSessions(ctx context.Context, userID uuid.UUID) ([]database.ListSessionsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	signedIn := make(map[uuid.UUID]time.Time)
+	for _, rt := range s.refreshTokens {
+		if t, ok := signedIn[rt.FamilyID]; !ok || rt.CreatedAt.Before(t) {
+			signedIn[rt.FamilyID] = rt.CreatedAt
+		}
+	}
+
+	var rows []database.ListSessionsRow
+	for _, rt := range s.refreshTokens {
+		if rt.UserID == userID && !rt.RevokedAt.Valid && rt.ExpiresAt.After(now) {
+			rows = append(rows, database.ListSessionsRow{
+				RefreshToken: rt,
+				SignedInAt:   signedIn[rt.FamilyID],
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i].RefreshToken, rows[j].RefreshToken
+		if !a.LastUsedAt.Equal(b.LastUsedAt) {
+			return a.LastUsedAt.After(b.LastUsedAt)
+		}
+		return bytes.Compare(a.FamilyID[:], b.FamilyID[:]) < 0
+	})
+	return rows, nil
+}
+
+func (s *Store) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for tokenHash, rt := range s.refreshTokens {
+		if rt.UserID == userID && !rt.RevokedAt.Valid {
+			rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			rt.UpdatedAt = now
+			s.refreshTokens[tokenHash] = rt
+		}
+	}
+	return nil
+}
+
+func (s *Store) RevokeOtherSessions(ctx context.Context, arg database.RevokeOtherSessionsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for tokenHash, rt := range s.refreshTokens {
+		if rt.UserID == arg.UserID && rt.FamilyID != arg.FamilyID && !rt.RevokedAt.Valid {
+			rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			rt.UpdatedAt = now
+			s.refreshTokens[tokenHash] = rt
+		}
+	}
+	return nil
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rt, ok := s.refreshTokens[tokenHash]
+	if !ok || rt.RevokedAt.Valid {
+		return nil
+	}
+	now := s.now()
+	rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	rt.UpdatedAt = now
+	s.refreshTokens[tokenHash] = rt
+	return nil
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for tokenHash, rt := range s.refreshTokens {
+		if rt.FamilyID == familyID && !rt.RevokedAt.Valid {
+			rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			rt.UpdatedAt = now
+			s.refreshTokens[tokenHash] = rt
+		}
+	}
+	return nil
+}
+
+func (s *Store) RevokeSession(ctx context.Context, arg database.RevokeSessionParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var n int64
+	for tokenHash, rt := range s.refreshTokens {
+		if rt.FamilyID == arg.FamilyID && rt.UserID == arg.UserID && !rt.RevokedAt.Valid && rt.ExpiresAt.After(now) {
+			rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			rt.UpdatedAt = now
+			s.refreshTokens[tokenHash] = rt
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,182 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) SearchChirps(ctx context.Context, arg database.SearchChirpsParams) ([]database.SearchChirpsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := parseSearchQuery(arg.Query)
+
+	var items []database.SearchChirpsRow
+	for _, c := range s.chirps {
+		if arg.AuthorID.Valid && c.UserID != arg.AuthorID.UUID {
+			continue
+		}
+		if arg.Since.Valid && c.CreatedAt.Before(arg.Since.Time) {
+			continue
+		}
+		if arg.Until.Valid && !c.CreatedAt.Before(arg.Until.Time) {
+			continue
+		}
+		if rank, ok := query.match(c.Body); ok {
+			items = append(items, database.SearchChirpsRow{Chirp: c, Rank: rank})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Rank != items[j].Rank {
+			return items[i].Rank > items[j].Rank
+		}
+		return compareChirpKey(items[i].Chirp, items[j].Chirp.CreatedAt, items[j].Chirp.ID) > 0
+	})
+
+	if int(arg.Offset) >= len(items) {
+		return nil, nil
+	}
+	items = items[arg.Offset:]
+	if len(items) > int(arg.Limit) {
+		items = items[:arg.Limit]
+	}
+	return items, nil
+}
+
+// searchQuery is a stand-in for Postgres' websearch_to_tsquery and english
+// text search configuration. It understands the same operators: words,
+// "quoted phrases", -excluded words or phrases, and OR between alternatives.
+// Words are lowercased and lightly stemmed instead of going through a real
+// dictionary, so it finds roughly, not exactly, what Postgres would.
+type searchQuery struct {
+	alternatives [][]searchClause
+}
+
+type searchClause struct {
+	words   []string
+	exclude bool
+}
+
+func parseSearchQuery(q string) searchQuery {
+	var query searchQuery
+	var clauses []searchClause
+
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		exclude := false
+		if q[0] == '-' {
+			exclude = true
+			q = q[1:]
+		}
+
+		var raw string
+		if strings.HasPrefix(q, `"`) {
+			end := strings.Index(q[1:], `"`)
+			if end < 0 {
+				raw, q = q[1:], ""
+			} else {
+				raw, q = q[1:end+1], q[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(q, unicode.IsSpace)
+			if end < 0 {
+				end = len(q)
+			}
+			raw, q = q[:end], q[end:]
+			if !exclude && strings.EqualFold(raw, "or") {
+				if len(clauses) > 0 {
+					query.alternatives = append(query.alternatives, clauses)
+					clauses = nil
+				}
+				continue
+			}
+		}
+
+		if words := searchWords(raw); len(words) > 0 {
+			clauses = append(clauses, searchClause{words: words, exclude: exclude})
+		}
+	}
+	if len(clauses) > 0 {
+		query.alternatives = append(query.alternatives, clauses)
+	}
+	return query
+}
+
+// match reports whether body matches the query, and scores how well. Like
+// ts_rank, more matches in a shorter body rank higher. As in Postgres, an
+// alternative made only of exclusions matches every body without them,
+// with a rank of 0.
+func (q searchQuery) match(body string) (rank float64, ok bool) {
+	words := searchWords(body)
+
+	best := -1
+	for _, clauses := range q.alternatives {
+		hits, matched := 0, true
+		for _, clause := range clauses {
+			n := countPhrase(words, clause.words)
+			if clause.exclude {
+				matched = n == 0
+			} else {
+				matched = n > 0
+				hits += n
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched && hits > best {
+			best = hits
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	if len(words) == 0 {
+		return 0, true
+	}
+	return float64(best) / float64(len(words)), true
+}
+
+// countPhrase counts the places phrase appears as consecutive words.
+func countPhrase(words, phrase []string) int {
+	n := 0
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j, w := range phrase {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			n++
+		}
+	}
+	return n
+}
+
+// searchWords splits s into lowercased, stemmed words.
+func searchWords(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, f := range fields {
+		fields[i] = stem(f)
+	}
+	return fields
+}
+
+// stem strips a few common English suffixes so that "chirps", "chirped" and
+// "chirping" all match "chirp".
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
@@ -0,0 +1,35 @@
+package memstore
+
+import "testing"
+
+func TestSearchQueryMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		body  string
+		match bool
+	}{
+		{name: "Word", query: "chemistry", body: "I love Chemistry!", match: true},
+		{name: "Missing word", query: "chemistry", body: "I love physics", match: false},
+		{name: "All words required", query: "love chemistry", body: "I love physics", match: false},
+		{name: "Stemmed", query: "cooking", body: "we cooked all night", match: true},
+		{name: "Phrase", query: `"blue sky"`, body: "the blue sky is pure", match: true},
+		{name: "Phrase out of order", query: `"blue sky"`, body: "the sky is blue", match: false},
+		{name: "Excluded word", query: "sky -blue", body: "the blue sky", match: false},
+		{name: "Excluded word absent", query: "sky -blue", body: "the grey sky", match: true},
+		{name: "Or", query: "physics or chemistry", body: "chemistry class", match: true},
+		{name: "Only exclusions", query: "-blue", body: "the grey sky", match: true},
+		{name: "Only exclusions, excluded", query: "-blue", body: "the blue sky", match: false},
+		{name: "Or exclusion", query: "physics or -chemistry", body: "biology class", match: true},
+		{name: "Empty query", query: "", body: "anything", match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rank, ok := parseSearchQuery(tt.query).match(tt.body)
+			if ok != tt.match {
+				t.Errorf("match(%q, %q) = %v, %v, want match %v", tt.query, tt.body, rank, ok, tt.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) CreateSigningKey(ctx context.Context, arg database.CreateSigningKeyParams) (database.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.signingKeys[arg.ID]; ok {
+		return database.SigningKey{}, uniqueViolation("signing_keys_pkey")
+	}
+	key := database.SigningKey{
+		ID:         arg.ID,
+		Algorithm:  arg.Algorithm,
+		PrivateKey: append([]byte(nil), arg.PrivateKey...),
+		CreatedAt:  s.now(),
+	}
+	s.signingKeys[key.ID] = key
+	return key, nil
+}
+
+func (s *Store) GetFirstSigningKeyCreatedAt(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var first time.Time
+	for _, key := range s.signingKeys {
+		if first.IsZero() || key.CreatedAt.Before(first) {
+			first = key.CreatedAt
+		}
+	}
+	if first.IsZero() {
+		return time.Time{}, sql.ErrNoRows
+	}
+	return first, nil
+}
+
+func (s *Store) ListSigningKeys(ctx context.Context) ([]database.SigningKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	var keys []database.SigningKey
+	for _, key := range s.signingKeys {
+		if !key.RetiresAt.Valid || key.RetiresAt.Time.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (s *Store) RetireSigningKeys(ctx context.Context, arg database.RetireSigningKeysParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, key := range s.signingKeys {
+		if !key.RetiresAt.Valid && id != arg.ExceptID {
+			key.RetiresAt = arg.RetiresAt
+			s.signingKeys[id] = key
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpdateSigningKeyPrivateKey(ctx context.Context, arg database.UpdateSigningKeyPrivateKeyParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.signingKeys[arg.ID]; ok {
+		key.PrivateKey = append([]byte(nil), arg.PrivateKey...)
+		s.signingKeys[arg.ID] = key
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package memstore is an in-memory implementation of database.Store. It
+// mirrors the Postgres schema closely enough to run the handlers without a
+// database: unique constraints and foreign keys are enforced and reported
+// as *pq.Error, missing rows are reported as sql.ErrNoRows, and deleting a
+// user cascades to everything that references it.
+package memstore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/moderation"
+)
+
+type Store struct {
+	mu sync.RWMutex
+
+	// Now returns the current time. Tests can replace it to control
+	// timestamps and expiry; it defaults to time.Now.
+	Now func() time.Time
+
+	users         map[uuid.UUID]database.User
+	chirps        map[uuid.UUID]database.Chirp
+	refreshTokens map[string]database.RefreshToken
+	follows       map[followKey]database.Follow
+	likes         map[likeKey]database.ChirpLike
+	hashtags      map[hashtagKey]database.ChirpHashtag
+	mentions      map[mentionKey]database.ChirpMention
+	notifications map[uuid.UUID]database.Notification
+	bannedWords   map[string]database.BannedWord
+	flags         map[uuid.UUID]database.FlaggedChirp
+	blocks        map[blockKey]database.Block
+	signingKeys   map[string]database.SigningKey
+	apiKeys       map[uuid.UUID]database.ApiKey
+	userTotp      map[uuid.UUID]database.UserTotp
+	recoveryCodes map[recoveryCodeKey]database.RecoveryCode
+	mfaChallenges map[string]database.MfaChallenge
+	resets        map[string]database.PasswordReset
+	verifications map[string]database.EmailVerification
+	loginFailures map[string]database.LoginFailure
+}
+
+var _ database.Store = (*Store)(nil)
+
+// New returns an empty store, apart from the banned words the migrations
+// seed.
+func New() *Store {
+	s := &Store{
+		Now:           time.Now,
+		users:         make(map[uuid.UUID]database.User),
+		chirps:        make(map[uuid.UUID]database.Chirp),
+		refreshTokens: make(map[string]database.RefreshToken),
+		follows:       make(map[followKey]database.Follow),
+		likes:         make(map[likeKey]database.ChirpLike),
+		hashtags:      make(map[hashtagKey]database.ChirpHashtag),
+		mentions:      make(map[mentionKey]database.ChirpMention),
+		notifications: make(map[uuid.UUID]database.Notification),
+		blocks:        make(map[blockKey]database.Block),
+		bannedWords:   make(map[string]database.BannedWord),
+		flags:         make(map[uuid.UUID]database.FlaggedChirp),
+		signingKeys:   make(map[string]database.SigningKey),
+		apiKeys:       make(map[uuid.UUID]database.ApiKey),
+		userTotp:      make(map[uuid.UUID]database.UserTotp),
+		recoveryCodes: make(map[recoveryCodeKey]database.RecoveryCode),
+		mfaChallenges: make(map[string]database.MfaChallenge),
+		resets:        make(map[string]database.PasswordReset),
+		verifications: make(map[string]database.EmailVerification),
+		loginFailures: make(map[string]database.LoginFailure),
+	}
+	for _, word := range moderation.DefaultWords {
+		s.bannedWords[word] = database.BannedWord{Word: word, CreatedAt: s.now()}
+	}
+	return s
+}
+
+// now matches Postgres TIMESTAMP precision so values round-trip the same way
+// they would through the database.
+func (s *Store) now() time.Time {
+	return s.Now().UTC().Truncate(time.Microsecond)
+}
+
+func uniqueViolation(constraint string) error {
+	return &pq.Error{
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return &pq.Error{
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// deleteUser removes a user and everything that references it, as the
+// ON DELETE CASCADE foreign keys do. The caller must hold s.mu.
+func (s *Store) deleteUser(id uuid.UUID) {
+	delete(s.users, id)
+	for chirpID, chirp := range s.chirps {
+		if chirp.UserID == id {
+			s.deleteChirp(chirpID)
+		}
+	}
+	for token, rt := range s.refreshTokens {
+		if rt.UserID == id {
+			delete(s.refreshTokens, token)
+		}
+	}
+	for key := range s.follows {
+		if key.follower == id || key.followee == id {
+			delete(s.follows, key)
+		}
+	}
+	for key := range s.likes {
+		if key.user == id {
+			delete(s.likes, key)
+		}
+	}
+	for key := range s.blocks {
+		if key.blocker == id || key.blocked == id {
+			delete(s.blocks, key)
+		}
+	}
+	for key, m := range s.mentions {
+		if m.UserID == id {
+			delete(s.mentions, key)
+		}
+	}
+	for nid, n := range s.notifications {
+		if n.UserID == id || n.ActorID == id {
+			delete(s.notifications, nid)
+		}
+	}
+	for keyID, key := range s.apiKeys {
+		if key.UserID == id {
+			delete(s.apiKeys, keyID)
+		}
+	}
+	delete(s.userTotp, id)
+	for key := range s.recoveryCodes {
+		if key.user == id {
+			delete(s.recoveryCodes, key)
+		}
+	}
+	for tokenHash, c := range s.mfaChallenges {
+		if c.UserID == id {
+			delete(s.mfaChallenges, tokenHash)
+		}
+	}
+	for tokenHash, reset := range s.resets {
+		if reset.UserID == id {
+			delete(s.resets, tokenHash)
+		}
+	}
+	for tokenHash, v := range s.verifications {
+		if v.UserID == id {
+			delete(s.verifications, tokenHash)
+		}
+	}
+}
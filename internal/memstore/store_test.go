@@ -0,0 +1,154 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/moderation"
+)
+
+func isPQCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+func TestCreateUserUniqueEmail(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	_, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x", Handle: "a"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	_, err = s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "y", Handle: "a2"})
+	if !isPQCode(err, "23505") {
+		t.Errorf("CreateUser() duplicate email error = %v, want unique violation", err)
+	}
+
+	other, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "b@example.com", HashedPassword: "z", Handle: "b"})
+	_, err = s.UpdateUser(ctx, database.UpdateUserParams{ID: other.ID, Email: "a@example.com", HashedPassword: "z"})
+	if !isPQCode(err, "23505") {
+		t.Errorf("UpdateUser() duplicate email error = %v, want unique violation", err)
+	}
+
+	_, err = s.CreateUser(ctx, database.CreateUserParams{Email: "c@example.com", HashedPassword: "x", Handle: "a"})
+	if !isPQCode(err, "23505") {
+		t.Errorf("CreateUser() duplicate handle error = %v, want unique violation", err)
+	}
+}
+
+func TestCreateChirpUnknownUser(t *testing.T) {
+	s := New()
+	_, err := s.CreateChirp(context.Background(), database.CreateChirpParams{Body: "hi", UserID: uuid.New()})
+	if !isPQCode(err, "23503") {
+		t.Errorf("CreateChirp() error = %v, want foreign key violation", err)
+	}
+}
+
+func TestDeleteAllCascades(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	user, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x", Handle: "a"})
+	chirp, _ := s.CreateChirp(ctx, database.CreateChirpParams{Body: "hi", UserID: user.ID})
+	s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		TokenHash: "tok",
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	if err := s.DeleteAll(ctx); err != nil {
+		t.Fatalf("DeleteAll() error = %v", err)
+	}
+	if _, err := s.GetChirpById(ctx, chirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetChirpById() after DeleteAll error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.GetUserFromRefreshToken(ctx, "tok"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserFromRefreshToken() after DeleteAll error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestGetUserFromRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	now := time.Now()
+	s.Now = func() time.Time { return now }
+
+	user, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x", Handle: "a"})
+	for _, tok := range []string{"valid", "revoked", "expired"} {
+		s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			TokenHash: tok,
+			UserID:    user.ID,
+			ExpiresAt: now.Add(time.Hour),
+		})
+	}
+	s.RevokeRefreshToken(ctx, "revoked")
+	s.refreshTokens["expired"] = func() database.RefreshToken {
+		rt := s.refreshTokens["expired"]
+		rt.ExpiresAt = now.Add(-time.Minute)
+		return rt
+	}()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "Valid token", token: "valid", wantErr: false},
+		{name: "Revoked token", token: "revoked", wantErr: true},
+		{name: "Expired token", token: "expired", wantErr: true},
+		{name: "Unknown token", token: "nope", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetUserFromRefreshToken(ctx, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetUserFromRefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.ID != user.ID {
+				t.Errorf("GetUserFromRefreshToken() user = %v, want %v", got.ID, user.ID)
+			}
+		})
+	}
+}
+
+func TestRevokeRefreshTokenKeepsRevokedAt(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now }
+
+	user, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x", Handle: "a"})
+	s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{TokenHash: "tok", UserID: user.ID, ExpiresAt: now.Add(time.Hour)})
+	s.RevokeRefreshToken(ctx, "tok")
+	revokedAt := now
+	now = now.Add(time.Minute)
+	s.RevokeRefreshToken(ctx, "tok")
+
+	rt, err := s.GetRefreshToken(ctx, "tok")
+	if err != nil {
+		t.Fatalf("GetRefreshToken() error = %v", err)
+	}
+	if !rt.RevokedAt.Time.Equal(revokedAt) {
+		t.Errorf("revoked_at = %v, want %v", rt.RevokedAt.Time, revokedAt)
+	}
+}
+
+func TestNewSeedsDefaultBannedWords(t *testing.T) {
+	words, err := New().ListBannedWords(context.Background())
+	if err != nil {
+		t.Fatalf("ListBannedWords() error = %v", err)
+	}
+	want := slices.Sorted(slices.Values(moderation.DefaultWords))
+	if slices.Sort(words); !slices.Equal(words, want) {
+		t.Errorf("ListBannedWords() = %v, want %v", words, want)
+	}
+}
@@ -0,0 +1,240 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.emailTaken(arg.Email, uuid.Nil) {
+		return database.User{}, uniqueViolation("users_email_key")
+	}
+	for _, user := range s.users {
+		if user.Handle == arg.Handle {
+			return database.User{}, uniqueViolation("users_handle_key")
+		}
+	}
+
+	now := s.now()
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Handle:         arg.Handle,
+	}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) DeleteAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.users {
+		s.deleteUser(id)
+	}
+	return nil
+}
+
+func (s *Store) GetTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return user.TokenVersion, nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *Store) GetUsersByHandles(ctx context.Context, handles []string) ([]database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(handles))
+	for _, handle := range handles {
+		wanted[handle] = true
+	}
+
+	var items []database.User
+	for _, user := range s.users {
+		if wanted[user.Handle] {
+			items = append(items, user)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) IncrementTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	user.TokenVersion++
+	s.users[id] = user
+	return user.TokenVersion, nil
+}
+
+func (s *Store) PatchUser(ctx context.Context, arg database.PatchUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	if arg.SetHandle {
+		for id, other := range s.users {
+			if id != arg.ID && other.Handle == arg.Handle {
+				return database.User{}, uniqueViolation("users_handle_key")
+			}
+		}
+		user.Handle = arg.Handle
+	}
+	if arg.SetHashedPassword {
+		user.HashedPassword = arg.HashedPassword
+	}
+	if arg.SetPendingEmail {
+		user.PendingEmail = arg.PendingEmail
+	}
+	if arg.SetDisplayName {
+		user.DisplayName = arg.DisplayName
+	}
+	if arg.SetBio {
+		user.Bio = arg.Bio
+	}
+	user.UpdatedAt = s.now()
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) RehashUserPassword(ctx context.Context, arg database.RehashUserPasswordParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[arg.ID]; ok && user.HashedPassword == arg.OldHash {
+		user.HashedPassword = arg.NewHash
+		s.users[arg.ID] = user
+	}
+	return nil
+}
+
+func (s *Store) SetPendingEmail(ctx context.Context, arg database.SetPendingEmailParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[arg.ID]; ok {
+		user.PendingEmail = arg.PendingEmail
+		user.UpdatedAt = s.now()
+		s.users[arg.ID] = user
+	}
+	return nil
+}
+
+func (s *Store) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	if s.emailTaken(arg.Email, arg.ID) {
+		return database.User{}, uniqueViolation("users_email_key")
+	}
+
+	user.Email = arg.Email
+	user.HashedPassword = arg.HashedPassword
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[arg.ID]; ok {
+		user.HashedPassword = arg.HashedPassword
+		user.UpdatedAt = s.now()
+		s.users[arg.ID] = user
+	}
+	return nil
+}
+
+func (s *Store) UpgradeUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.IsChirpyRed = true
+		s.users[id] = user
+	}
+	return nil
+}
+
+func (s *Store) VerifyUserEmail(ctx context.Context, arg database.VerifyUserEmailParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	if s.emailTaken(arg.Email, arg.ID) {
+		return database.User{}, uniqueViolation("users_email_key")
+	}
+
+	user.Email = arg.Email
+	user.VerifiedAt = sql.NullTime{Time: s.now(), Valid: true}
+	if user.PendingEmail.String == arg.Email {
+		user.PendingEmail = sql.NullString{}
+	}
+	user.UpdatedAt = s.now()
+	s.users[arg.ID] = user
+	return user, nil
+}
+
+// emailTaken reports whether a user other than except already has email.
+// The caller must hold s.mu.
+func (s *Store) emailTaken(email string, except uuid.UUID) bool {
+	for id, user := range s.users {
+		if id != except && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package moderation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileWordStore keeps banned words in a text file, one per line. Blank lines
+// and lines starting with # are ignored. Add and Remove rewrite the file,
+// so comments do not survive an edit.
+type FileWordStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+var _ WordStore = (*FileWordStore)(nil)
+
+func (s *FileWordStore) Load(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+func (s *FileWordStore) Add(ctx context.Context, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	words, err := s.read()
+	if err != nil {
+		return err
+	}
+	for _, w := range words {
+		if w == word {
+			return nil
+		}
+	}
+	return s.write(append(words, word))
+}
+
+func (s *FileWordStore) Remove(ctx context.Context, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	words, err := s.read()
+	if err != nil {
+		return err
+	}
+	kept := words[:0]
+	for _, w := range words {
+		if w != word {
+			kept = append(kept, w)
+		}
+	}
+	return s.write(kept)
+}
+
+func (s *FileWordStore) read() ([]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var words []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// write replaces the file through a rename so a crash never leaves it half
+// written.
+func (s *FileWordStore) write(words []string) error {
+	sort.Strings(words)
+	data := "# Banned words, one per line.\n" + strings.Join(words, "\n") + "\n"
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".banned-words-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.WriteString(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
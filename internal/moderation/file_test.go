@@ -0,0 +1,44 @@
+package moderation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileWordStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# comment\nkerfuffle\n\n  fornax  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := &FileWordStore{Path: path}
+
+	l := NewWordList(store, PolicyReplace)
+	if err := l.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, want := l.Words(), []string{"fornax", "kerfuffle"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %q, want %q", got, want)
+	}
+
+	l.Add(ctx, "sharbert")
+	l.Add(ctx, "sharbert")
+	l.Remove(ctx, "fornax")
+
+	// A fresh list reads the edits back from the file.
+	reloaded := NewWordList(&FileWordStore{Path: path}, PolicyReplace)
+	if err := reloaded.Load(ctx); err != nil {
+		t.Fatalf("Load() after edits error = %v", err)
+	}
+	if got, want := reloaded.Words(), []string{"kerfuffle", "sharbert"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() after edits = %q, want %q", got, want)
+	}
+
+	missing := NewWordList(&FileWordStore{Path: filepath.Join(t.TempDir(), "missing.txt")}, PolicyReplace)
+	if err := missing.Load(ctx); err == nil {
+		t.Error("Load() of a missing file error = nil, want an error")
+	}
+}
@@ -0,0 +1,64 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzModerate checks the replace policy's invariants on arbitrary input.
+// Seeds live in testdata/fuzz/FuzzModerate alongside the ones added here.
+func FuzzModerate(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"kerfuffle",
+		"Kerfuffle!",
+		"ｋｅｒｆｕｆｆｌｅ",
+		"kerfuffle's sharbert-fornax",
+		"****",
+		"\xffkerfuffle\xfe",
+	} {
+		f.Add(seed)
+	}
+
+	// Seeds whose result is known, including those in testdata.
+	want := map[string]string{
+		"kerfuffle":              Replacement,
+		"Kerfuffle!":             Replacement + "!",
+		"kerfuffle\u0301 fornax": Replacement + " " + Replacement,
+	}
+
+	l := NewWordList(memWords(DefaultWords), PolicyReplace)
+	if err := l.Load(context.Background()); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, body string) {
+		res, err := l.Moderate(context.Background(), body)
+		if err != nil {
+			t.Fatalf("Moderate(%q) error = %v", body, err)
+		}
+		if w, ok := want[body]; ok && res.Body != w {
+			t.Errorf("Moderate(%q) = %q, want %q", body, res.Body, w)
+		}
+		if len(res.Matches) == 0 && res.Body != body {
+			t.Errorf("Moderate(%q) changed a chirp with no matches to %q", body, res.Body)
+		}
+		if utf8.ValidString(body) && !utf8.ValidString(res.Body) {
+			t.Errorf("Moderate(%q) = %q, not valid UTF-8", body, res.Body)
+		}
+
+		// Masking is complete: nothing banned is left to find, and a second
+		// pass is a no-op.
+		again, _ := l.Moderate(context.Background(), res.Body)
+		if len(again.Matches) != 0 || again.Body != res.Body {
+			t.Errorf("Moderate(%q) = %q, which still matches %q", body, res.Body, again.Matches)
+		}
+
+		// Text outside the matched words is kept as is.
+		if len(res.Matches) > 0 && strings.Count(res.Body, Replacement) < len(res.Matches) {
+			t.Errorf("Moderate(%q) = %q, want at least %d replacements", body, res.Body, len(res.Matches))
+		}
+	})
+}
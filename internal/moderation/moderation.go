@@ -0,0 +1,47 @@
+// Package moderation decides what happens to chirps that contain banned
+// words.
+package moderation
+
+import (
+	"context"
+	"fmt"
+)
+
+// Policy is what a Moderator does with a chirp that contains banned words.
+type Policy string
+
+const (
+	// PolicyReplace masks each banned word and publishes the chirp.
+	PolicyReplace Policy = "replace"
+	// PolicyReject refuses to publish the chirp.
+	PolicyReject Policy = "reject"
+	// PolicyFlag publishes the chirp unchanged and flags it for review.
+	PolicyFlag Policy = "flag"
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicyReplace, PolicyReject, PolicyFlag:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown moderation policy %q: want replace, reject or flag", s)
+}
+
+// Result is a Moderator's verdict on a chirp body.
+type Result struct {
+	// Body is the text to publish. It is the input with any masking
+	// applied.
+	Body string
+	// Matches lists the banned words found, normalized, each once, in the
+	// order they first appear.
+	Matches []string
+	// Rejected is set when the chirp must not be published.
+	Rejected bool
+	// Flagged is set when the chirp should be published and reviewed.
+	Flagged bool
+}
+
+// A Moderator checks chirp bodies before they are published.
+type Moderator interface {
+	Moderate(ctx context.Context, body string) (Result, error)
+}
@@ -0,0 +1,215 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Replacement is what PolicyReplace puts in place of a banned word.
+const Replacement = "****"
+
+// DefaultWords is the list a new database starts with. The migration that
+// creates banned_words seeds the same list.
+var DefaultWords = []string{"kerfuffle", "sharbert", "fornax"}
+
+var ErrInvalidWord = errors.New("banned words must be a single word of letters and digits")
+
+// WordStore persists a WordList's words.
+type WordStore interface {
+	Load(ctx context.Context) ([]string, error)
+	Add(ctx context.Context, word string) error
+	Remove(ctx context.Context, word string) error
+}
+
+// WordList is a Moderator that matches whole words against a list of
+// banned words. Words are runs of letters, digits and combining marks, so
+// punctuation never hides a match ("Kerfuffle!"), and are compared after
+// folding case, accents and full-width forms, so "KERFUFFLE", "kérfuffle"
+// and "ｋｅｒｆｕｆｆｌｅ" match "kerfuffle" too.
+type WordList struct {
+	store  WordStore
+	policy Policy
+
+	mu    sync.RWMutex
+	words map[string]bool
+}
+
+var _ Moderator = (*WordList)(nil)
+
+// NewWordList returns an empty list backed by store. Call Load to read the
+// stored words.
+func NewWordList(store WordStore, policy Policy) *WordList {
+	return &WordList{
+		store:  store,
+		policy: policy,
+		words:  map[string]bool{},
+	}
+}
+
+// Load replaces the words in memory with the ones in the store.
+func (l *WordList) Load(ctx context.Context) error {
+	stored, err := l.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	words := make(map[string]bool, len(stored))
+	for _, w := range stored {
+		if w, err := Normalize(w); err == nil {
+			words[w] = true
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.words = words
+	return nil
+}
+
+func (l *WordList) Policy() Policy {
+	return l.policy
+}
+
+// Words returns the banned words, sorted.
+func (l *WordList) Words() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	words := make([]string, 0, len(l.words))
+	for w := range l.words {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Add bans a word and returns it normalized.
+func (l *WordList) Add(ctx context.Context, word string) (string, error) {
+	word, err := Normalize(word)
+	if err != nil {
+		return "", err
+	}
+	if err := l.store.Add(ctx, word); err != nil {
+		return "", err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.words[word] = true
+	return word, nil
+}
+
+// Remove unbans a word. Removing a word that is not banned is not an error.
+func (l *WordList) Remove(ctx context.Context, word string) error {
+	word, err := Normalize(word)
+	if err != nil {
+		return err
+	}
+	if err := l.store.Remove(ctx, word); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.words, word)
+	return nil
+}
+
+func (l *WordList) Moderate(ctx context.Context, body string) (Result, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var b strings.Builder
+	var matches []string
+	seen := map[string]bool{}
+	rest := body
+	for rest != "" {
+		start, end := nextWord(rest)
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:start])
+		word := fold(rest[start:end])
+		if l.words[word] {
+			if !seen[word] {
+				seen[word] = true
+				matches = append(matches, word)
+			}
+			b.WriteString(Replacement)
+		} else {
+			b.WriteString(rest[start:end])
+		}
+		rest = rest[end:]
+	}
+
+	res := Result{Body: body, Matches: matches}
+	if len(matches) == 0 {
+		return res, nil
+	}
+	switch l.policy {
+	case PolicyReject:
+		res.Rejected = true
+	case PolicyFlag:
+		res.Flagged = true
+	default:
+		res.Body = b.String()
+	}
+	return res, nil
+}
+
+// Normalize returns word in the form the list stores it in, or
+// ErrInvalidWord if it is not exactly one word.
+func Normalize(word string) (string, error) {
+	word = strings.TrimSpace(word)
+	if start, end := nextWord(word); start != 0 || end != len(word) {
+		return "", ErrInvalidWord
+	}
+	word = fold(word)
+	if word == "" {
+		return "", ErrInvalidWord
+	}
+	return word, nil
+}
+
+// nextWord returns the byte offsets of the first word in s, or -1, -1 if
+// there is none.
+func nextWord(s string) (start, end int) {
+	start = strings.IndexFunc(s, isWordRune)
+	if start < 0 {
+		return -1, -1
+	}
+	end = strings.IndexFunc(s[start:], func(r rune) bool { return !isWordRune(r) })
+	if end < 0 {
+		return start, len(s)
+	}
+	return start, start + end
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// fold decomposes accented letters and drops the combining marks, maps
+// full-width forms to ASCII and lowercases, so that visually equivalent
+// spellings of a word compare equal: "kérfuffle" and "kerfuffle\u0301" fold
+// to "kerfuffle".
+func fold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if '！' <= r && r <= '～' {
+			r -= '！' - '!'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
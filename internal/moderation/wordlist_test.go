@@ -0,0 +1,151 @@
+package moderation
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// memWords is a WordStore that keeps nothing beyond the words it starts with.
+type memWords []string
+
+func (m memWords) Load(ctx context.Context) ([]string, error)    { return m, nil }
+func (m memWords) Add(ctx context.Context, word string) error    { return nil }
+func (m memWords) Remove(ctx context.Context, word string) error { return nil }
+
+func newTestList(t *testing.T, policy Policy) *WordList {
+	t.Helper()
+	l := NewWordList(memWords(DefaultWords), policy)
+	if err := l.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return l
+}
+
+func TestModerateReplace(t *testing.T) {
+	l := newTestList(t, PolicyReplace)
+
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		matches []string
+	}{
+		{name: "Clean", body: "I had something interesting for breakfast", want: "I had something interesting for breakfast"},
+		{name: "Lowercase", body: "what a kerfuffle today", want: "what a **** today", matches: []string{"kerfuffle"}},
+		{name: "Mixed case", body: "I hear Mastodon is better than Chirpy. sharbert I need to migrate", want: "I hear Mastodon is better than Chirpy. **** I need to migrate", matches: []string{"sharbert"}},
+		{name: "Punctuation", body: "Kerfuffle! (fornax), 'sharbert'.", want: "****! (****), '****'.", matches: []string{"kerfuffle", "fornax", "sharbert"}},
+		{name: "Repeated", body: "fornax fornax", want: "**** ****", matches: []string{"fornax"}},
+		{name: "Inside a longer word", body: "kerfuffles and sharberty", want: "kerfuffles and sharberty"},
+		{name: "Unicode case", body: "KERFUFFLE Ｆｏｒｎａｘ", want: "**** ****", matches: []string{"kerfuffle", "fornax"}},
+		{name: "Kelvin sign", body: "\u212Aerfuffle", want: "****", matches: []string{"kerfuffle"}},
+		{name: "Other scripts stay", body: "ΚΑΛΗΜΕΡΑ kerfuffle 日本", want: "ΚΑΛΗΜΕΡΑ **** 日本", matches: []string{"kerfuffle"}},
+		{name: "Combining mark", body: "kerfuffle\u0301 fornax", want: "**** ****", matches: []string{"kerfuffle", "fornax"}},
+		{name: "Accented letter", body: "k\u00e9rfuffle", want: "****", matches: []string{"kerfuffle"}},
+		{name: "Newlines", body: "a\nkerfuffle\tb", want: "a\n****\tb", matches: []string{"kerfuffle"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := l.Moderate(context.Background(), tt.body)
+			if err != nil {
+				t.Fatalf("Moderate() error = %v", err)
+			}
+			if res.Body != tt.want {
+				t.Errorf("Moderate(%q).Body = %q, want %q", tt.body, res.Body, tt.want)
+			}
+			if !reflect.DeepEqual(res.Matches, tt.matches) {
+				t.Errorf("Moderate(%q).Matches = %q, want %q", tt.body, res.Matches, tt.matches)
+			}
+			if res.Rejected || res.Flagged {
+				t.Errorf("Moderate(%q) rejected = %v, flagged = %v, want neither", tt.body, res.Rejected, res.Flagged)
+			}
+		})
+	}
+}
+
+func TestModeratePolicies(t *testing.T) {
+	tests := []struct {
+		policy       Policy
+		wantBody     string
+		wantRejected bool
+		wantFlagged  bool
+	}{
+		{policy: PolicyReplace, wantBody: "such a ****"},
+		{policy: PolicyReject, wantBody: "such a kerfuffle", wantRejected: true},
+		{policy: PolicyFlag, wantBody: "such a kerfuffle", wantFlagged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			l := newTestList(t, tt.policy)
+			res, _ := l.Moderate(context.Background(), "such a kerfuffle")
+			if res.Body != tt.wantBody || res.Rejected != tt.wantRejected || res.Flagged != tt.wantFlagged {
+				t.Errorf("Moderate() = %+v, want body %q, rejected %v, flagged %v", res, tt.wantBody, tt.wantRejected, tt.wantFlagged)
+			}
+
+			// A combining mark does not hide the word from any policy.
+			marked, _ := l.Moderate(context.Background(), "such a kerfuffle\u0301")
+			if len(marked.Matches) != 1 || marked.Rejected != tt.wantRejected || marked.Flagged != tt.wantFlagged {
+				t.Errorf("Moderate() with a combining mark = %+v, want it to match", marked)
+			}
+
+			clean, _ := l.Moderate(context.Background(), "all good")
+			if clean.Body != "all good" || clean.Rejected || clean.Flagged {
+				t.Errorf("Moderate() on a clean chirp = %+v", clean)
+			}
+		})
+	}
+}
+
+func TestWordListEdits(t *testing.T) {
+	ctx := context.Background()
+	l := newTestList(t, PolicyReplace)
+
+	if w, err := l.Add(ctx, "  Heck "); err != nil || w != "heck" {
+		t.Errorf("Add() = %q, %v, want %q", w, err, "heck")
+	}
+	for _, bad := range []string{"", "two words", "heck!"} {
+		if _, err := l.Add(ctx, bad); err != ErrInvalidWord {
+			t.Errorf("Add(%q) error = %v, want ErrInvalidWord", bad, err)
+		}
+	}
+	if err := l.Remove(ctx, "FORNAX"); err != nil {
+		t.Errorf("Remove() error = %v", err)
+	}
+
+	if got, want := l.Words(), []string{"heck", "kerfuffle", "sharbert"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %q, want %q", got, want)
+	}
+	res, _ := l.Moderate(ctx, "heck, fornax")
+	if res.Body != "****, fornax" {
+		t.Errorf("Moderate() after edits = %q, want %q", res.Body, "****, fornax")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, s := range []string{"replace", "reject", "flag"} {
+		if p, err := ParsePolicy(s); err != nil || string(p) != s {
+			t.Errorf("ParsePolicy(%q) = %q, %v", s, p, err)
+		}
+	}
+	if _, err := ParsePolicy("ignore"); err == nil {
+		t.Error("ParsePolicy(\"ignore\") error = nil, want an error")
+	}
+}
+
+func TestDefaultWordsMatchMigration(t *testing.T) {
+	migration, err := os.ReadFile("../../sql/schema/017_banned_words.sql")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var seeded []string
+	for _, m := range regexp.MustCompile(`\('(\w+)', NOW\(\)\)`).FindAllStringSubmatch(string(migration), -1) {
+		seeded = append(seeded, m[1])
+	}
+	if !reflect.DeepEqual(seeded, DefaultWords) {
+		t.Errorf("017_banned_words.sql seeds %v, want DefaultWords %v", seeded, DefaultWords)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+const (
+	accessTokenTTL = time.Hour
+	// jwksMaxAge is how long clients may cache /.well-known/jwks.json.
+	jwksMaxAge = 5 * time.Minute
+	// defaultKeyOverlap is how long a replaced signing key is still
+	// accepted and published. It has to outlast the tokens the key signed
+	// and any cached copy of the JWKS.
+	defaultKeyOverlap        = 24 * time.Hour
+	minKeyOverlap            = accessTokenTTL + jwksMaxAge
+	signingKeyReloadInterval = time.Minute
+)
+
+// dbKeyStore keeps the signing keys in the signing_keys table, encrypted
+// with cipher unless it is nil.
+type dbKeyStore struct {
+	db     database.Store
+	cipher *auth.KeyCipher
+}
+
+var _ auth.KeyStore = dbKeyStore{}
+
+func (s dbKeyStore) Load(ctx context.Context) ([]auth.SigningKey, error) {
+	rows, err := s.db.ListSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]auth.SigningKey, 0, len(rows))
+	for _, row := range rows {
+		der := row.PrivateKey
+		if s.cipher != nil {
+			der, err = s.cipher.Open(row.ID, row.PrivateKey)
+			if errors.Is(err, auth.ErrKeyNotSealed) {
+				// Keys stored before they were encrypted are encrypted in
+				// place.
+				der = row.PrivateKey
+				err = s.db.UpdateSigningKeyPrivateKey(ctx, database.UpdateSigningKeyPrivateKeyParams{
+					ID:         row.ID,
+					PrivateKey: s.cipher.Seal(row.ID, der),
+				})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("signing key %s: %w", row.ID, err)
+			}
+		}
+		key, err := auth.ParseSigningKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", row.ID, err)
+		}
+		keys = append(keys, auth.SigningKey{
+			ID:        row.ID,
+			Algorithm: row.Algorithm,
+			Key:       key,
+			CreatedAt: row.CreatedAt,
+			RetiresAt: row.RetiresAt.Time,
+		})
+	}
+	return keys, nil
+}
+
+func (s dbKeyStore) Add(ctx context.Context, key auth.SigningKey, retireOthersAt time.Time) error {
+	der, err := auth.MarshalSigningKey(key.Key)
+	if err != nil {
+		return err
+	}
+	if s.cipher != nil {
+		der = s.cipher.Seal(key.ID, der)
+	}
+
+	// The new key goes in first, so that there is always a key that can
+	// sign.
+	_, err = s.db.CreateSigningKey(ctx, database.CreateSigningKeyParams{
+		ID:         key.ID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: der,
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.RetireSigningKeys(ctx, database.RetireSigningKeysParams{
+		RetiresAt: sql.NullTime{Time: retireOthersAt, Valid: true},
+		ExceptID:  key.ID,
+	})
+}
+
+func (s dbKeyStore) FirstCreatedAt(ctx context.Context) (time.Time, error) {
+	createdAt, err := s.db.GetFirstSigningKeyCreatedAt(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return createdAt, err
+}
+
+// newKeyring builds the access token keyring from the environment:
+// SIGNING_KEY_ENCRYPTION_KEY, 32 bytes in base64, to encrypt the private
+// keys with, which only the dev platform may leave unset; JWT_ALGORITHM
+// (EdDSA or RS256) for new keys; JWT_KEY_OVERLAP for how long replaced keys
+// are kept; and SECRET, if set, for tokens signed before keys were
+// introduced, which are accepted for accessTokenTTL after the first signing
+// key was made.
+// It does not load the keys.
+func newKeyring(store database.Store, platform string) (*auth.Keyring, error) {
+	var cipher *auth.KeyCipher
+	if s := os.Getenv("SIGNING_KEY_ENCRYPTION_KEY"); s != "" {
+		key, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("SIGNING_KEY_ENCRYPTION_KEY: %w", err)
+		}
+		cipher, err = auth.NewKeyCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("SIGNING_KEY_ENCRYPTION_KEY: %w", err)
+		}
+	} else if platform != "dev" {
+		return nil, errors.New("SIGNING_KEY_ENCRYPTION_KEY must be set")
+	}
+
+	overlap := defaultKeyOverlap
+	if s := os.Getenv("JWT_KEY_OVERLAP"); s != "" {
+		var err error
+		overlap, err = time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_KEY_OVERLAP: %w", err)
+		}
+		if overlap < minKeyOverlap {
+			return nil, fmt.Errorf("JWT_KEY_OVERLAP must be at least %s", minKeyOverlap)
+		}
+	}
+
+	keys, err := auth.NewKeyring(dbKeyStore{db: store, cipher: cipher}, cmp.Or(os.Getenv("JWT_ALGORITHM"), auth.AlgEdDSA), overlap)
+	if err != nil {
+		return nil, err
+	}
+	keys.LegacySecret = os.Getenv("SECRET")
+	keys.LegacyMaxAge = accessTokenTTL
+	return keys, nil
+}
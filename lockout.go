@@ -0,0 +1,98 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/lockout"
+)
+
+// loginFailurePruneInterval is how often forgotten login failures are
+// deleted from the database.
+const loginFailurePruneInterval = time.Hour
+
+// dbFailureStore keeps login failures in the login_failures table, so they
+// survive restarts and are shared between servers.
+type dbFailureStore struct {
+	db database.Store
+}
+
+var _ lockout.Store = dbFailureStore{}
+
+func (s dbFailureStore) Get(ctx context.Context, key string) (lockout.Record, error) {
+	f, err := s.db.GetLoginFailures(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return lockout.Record{}, nil
+	}
+	if err != nil {
+		return lockout.Record{}, err
+	}
+	return lockout.Record{Key: f.Key, Failures: f.Failures, LastFailedAt: f.LastFailedAt}, nil
+}
+
+func (s dbFailureStore) AddFailure(ctx context.Context, key string, at, forgetBefore time.Time) (lockout.Record, error) {
+	f, err := s.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:          key,
+		At:           at,
+		ForgetBefore: forgetBefore,
+	})
+	if err != nil {
+		return lockout.Record{}, err
+	}
+	return lockout.Record{Key: f.Key, Failures: f.Failures, LastFailedAt: f.LastFailedAt}, nil
+}
+
+func (s dbFailureStore) Delete(ctx context.Context, key string) error {
+	return s.db.DeleteLoginFailures(ctx, key)
+}
+
+// prune deletes failures older than forgetAfter every interval until ctx is
+// done.
+func (s dbFailureStore) prune(ctx context.Context, interval, forgetAfter time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.db.DeleteStaleLoginFailures(ctx, time.Now().Add(-forgetAfter)); err != nil {
+			log.Printf("Error deleting stale login failures: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newLoginLimiter returns the limiter LOGIN_LOCKOUT_STORE configures:
+// memory (the default) to count failed logins in this process, or db to
+// count them in the database.
+func newLoginLimiter(store database.Store) (*lockout.Limiter, error) {
+	switch kind := cmp.Or(os.Getenv("LOGIN_LOCKOUT_STORE"), "memory"); kind {
+	case "memory":
+		return lockout.New(lockout.NewMemoryStore()), nil
+	case "db":
+		limiter := lockout.New(dbFailureStore{db: store})
+		forgetAfter := max(limiter.Account.ForgetAfter, limiter.IP.ForgetAfter)
+		go dbFailureStore{db: store}.prune(context.Background(), loginFailurePruneInterval, forgetAfter)
+		return limiter, nil
+	default:
+		return nil, fmt.Errorf("unknown LOGIN_LOCKOUT_STORE %q: want memory or db", kind)
+	}
+}
+
+// respondTooManyLogins turns away a login that has to wait, saying how long
+// for in whole seconds.
+func respondTooManyLogins(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondWithProblem(w, http.StatusTooManyRequests, "Too many failed logins; try again later")
+}
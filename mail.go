@@ -0,0 +1,38 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+
+	"github.com/mrbaker1917/chirpy/internal/mailer"
+)
+
+// newMailer returns the mailer the environment configures: SMTP_ADDR (with
+// SMTP_USERNAME and SMTP_PASSWORD if the server needs them) to send through
+// an SMTP server, or MAIL_FILE to append emails to a file. MAIL_FROM is the
+// sender. With neither, emails are logged on the dev platform and there is
+// no mailer anywhere else.
+func newMailer(platform string) (mailer.Mailer, error) {
+	from := cmp.Or(os.Getenv("MAIL_FROM"), "chirpy@localhost")
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("SMTP_ADDR: %w", err)
+		}
+		m := &mailer.SMTPMailer{Addr: addr, From: from}
+		if username := os.Getenv("SMTP_USERNAME"); username != "" {
+			m.Auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		return m, nil
+	}
+	if path := os.Getenv("MAIL_FILE"); path != "" {
+		return &mailer.FileMailer{Path: path, From: from}, nil
+	}
+	if platform == "dev" {
+		return mailer.LogMailer{}, nil
+	}
+	return nil, nil
+}
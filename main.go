@@ -1,34 +1,105 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/mrbaker1917/chirpy/internal/auth"
 	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/lockout"
+	"github.com/mrbaker1917/chirpy/internal/mailer"
+	"github.com/mrbaker1917/chirpy/internal/memstore"
+	"github.com/mrbaker1917/chirpy/internal/moderation"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
-	db             *database.Queries
+	db             database.Store
 	platform       string
-	secret         string
+	keys           *auth.Keyring
+	tokenVersions  *tokenVersionCache
 	polka_key      string
+	admin_key      string
+	trending       *trendingHashtags
+	moderator      moderation.Moderator
+	// bannedWords is the list the admin moderation endpoints edit. It is
+	// nil when moderator is not a word list.
+	bannedWords *moderation.WordList
+	// mailer sends password reset emails. It is nil when none is
+	// configured, which turns password reset off.
+	mailer mailer.Mailer
+	// passwordResetURL, if set, is the page reset emails link to, with the
+	// token added as ?token=.
+	passwordResetURL string
+	// emailVerificationURL is passwordResetURL for verification emails.
+	emailVerificationURL string
+	// verifiedEmailRequired stops users chirping until they have verified
+	// their email.
+	verifiedEmailRequired bool
+	// passwordParams are the argon2id parameters new password hashes are
+	// made with. Hashes made with others are replaced when their user logs
+	// in.
+	passwordParams auth.PasswordParams
+	// passwordPolicy is what new passwords have to be.
+	passwordPolicy auth.PasswordPolicy
+	// logins counts failed logins to slow down password guessing.
+	logins *lockout.Limiter
+	// now is the clock used to check TOTP codes and expire MFA challenges,
+	// so that tests can move it.
+	now func() time.Time
+	// background tracks work that carries on after its response has been
+	// sent, such as password reset emails, so that tests can wait for it.
+	background sync.WaitGroup
 }
 
 type User struct {
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	// PendingEmail is an address the user has asked to change to that is
+	// not verified yet.
+	PendingEmail string `json:"pending_email,omitempty"`
+	Handle       string `json:"handle"`
+	DisplayName  string `json:"display_name,omitempty"`
+	Bio          string `json:"bio,omitempty"`
+	IsChirpyRed  bool   `json:"is_chirpy_red"`
+}
+
+func newUser(user database.User) User {
+	return User{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.VerifiedAt.Valid,
+		PendingEmail:  user.PendingEmail.String,
+		Handle:        user.Handle,
+		DisplayName:   user.DisplayName.String,
+		Bio:           user.Bio.String,
+		IsChirpyRed:   user.IsChirpyRed,
+	}
+}
+
+// UserSummary is the public view of a user shown in other people's lists.
+type UserSummary struct {
 	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle"`
 	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
 }
 
@@ -42,6 +113,20 @@ type Chirp struct {
 
 // helpers:
 
+// isUniqueViolation reports whether err is Postgres rejecting a write
+// because it breaks the named unique constraint or index.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// isForeignKeyViolation reports whether err is Postgres rejecting a write
+// because it breaks the named foreign key constraint.
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == constraint
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	dat, err := json.Marshal(payload)
 	if err != nil {
@@ -61,6 +146,25 @@ func respondWithError(w http.ResponseWriter, code int, msg string) {
 	respondWithJSON(w, code, payld)
 }
 
+// fieldError is what is wrong with one field of a request.
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// respondWithFieldErrors rejects a request with a 400, listing what is
+// wrong with each of its fields as well as the usual error.
+func respondWithFieldErrors(w http.ResponseWriter, msg string, errs []fieldError) {
+	respondWithJSON(w, 400, struct {
+		Error  string       `json:"error"`
+		Fields []fieldError `json:"fields"`
+	}{
+		Error:  msg,
+		Fields: errs,
+	})
+}
+
 func (apiCfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		apiCfg.fileserverHits.Add(1)
@@ -87,46 +191,174 @@ func handlerReadiness(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
 
+// routes registers the API. Routes that act as a user are wrapped in the
+// scope their access token must carry; reading chirps is open to anyone,
+// but a token sent along must still allow chirps:read.
+func (apiCfg *apiConfig) routes(filepathRoot string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("GET /admin/moderation/words", apiCfg.requireAdmin(apiCfg.handlerListBannedWords))
+	mux.HandleFunc("PUT /admin/moderation/words/{word}", apiCfg.requireAdmin(apiCfg.handlerAddBannedWord))
+	mux.HandleFunc("DELETE /admin/moderation/words/{word}", apiCfg.requireAdmin(apiCfg.handlerRemoveBannedWord))
+	mux.HandleFunc("GET /admin/moderation/flagged", apiCfg.requireAdmin(apiCfg.handlerListFlaggedChirps))
+	mux.HandleFunc("DELETE /admin/moderation/flagged/{chirpID}", apiCfg.requireAdmin(apiCfg.handlerUnflagChirp))
+	mux.HandleFunc("POST /admin/lockouts/unlock", apiCfg.requireAdmin(apiCfg.handlerUnlockLogins))
+	mux.HandleFunc("GET /admin/keys", apiCfg.requireAdmin(apiCfg.handlerListSigningKeys))
+	mux.HandleFunc("POST /admin/keys/rotate", apiCfg.requireAdmin(apiCfg.handlerRotateSigningKey))
+	mux.HandleFunc("POST /admin/tokens", apiCfg.requireAdmin(apiCfg.handlerAdminMintToken))
+	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))))
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
+	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
+	mux.HandleFunc("POST /api/users/verify", apiCfg.handlerVerifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerResendVerification))
+	mux.HandleFunc("POST /api/chirps", apiCfg.requireScope(auth.ScopeChirpsWrite, apiCfg.requireVerifiedEmail(apiCfg.handlerCreateChirp)))
+	mux.HandleFunc("GET /api/chirps", apiCfg.scopeIfAuthenticated(auth.ScopeChirpsRead, apiCfg.handlerGetChirps))
+	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.scopeIfAuthenticated(auth.ScopeChirpsRead, apiCfg.handlerGetChirpById))
+	mux.HandleFunc("GET /api/chirps/{chirpID}/replies", apiCfg.scopeIfAuthenticated(auth.ScopeChirpsRead, apiCfg.handlerGetReplies))
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.scopeIfAuthenticated(auth.ScopeChirpsRead, apiCfg.handlerGetThread))
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/like", apiCfg.requireScope(auth.ScopeChirpsWrite, apiCfg.handlerLikeChirp))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.requireScope(auth.ScopeChirpsWrite, apiCfg.handlerUnlikeChirp))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.requireScope(auth.ScopeChirpsWrite, apiCfg.requireVerifiedEmail(apiCfg.handlerRechirp)))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.requireScope(auth.ScopeChirpsWrite, apiCfg.handlerUndoRechirp))
+	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.handlerLoginMFA)
+	mux.HandleFunc("GET /api/mfa/totp", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerGetTOTP))
+	mux.HandleFunc("POST /api/mfa/totp", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerEnrollTOTP))
+	mux.HandleFunc("POST /api/mfa/totp/confirm", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerConfirmTOTP))
+	mux.HandleFunc("DELETE /api/mfa/totp", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerDisableTOTP))
+	mux.HandleFunc("POST /api/password/forgot", apiCfg.handlerForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", apiCfg.handlerResetPassword)
+	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
+	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	mux.HandleFunc("POST /api/keys", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerCreateAPIKey))
+	mux.HandleFunc("GET /api/keys", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerListAPIKeys))
+	mux.HandleFunc("DELETE /api/keys/{keyID}", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerDeleteAPIKey))
+	mux.HandleFunc("POST /api/tokens", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerMintToken))
+	mux.HandleFunc("GET /api/sessions", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerListSessions))
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerRevokeSession))
+	mux.HandleFunc("POST /api/sessions/revoke-all", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerRevokeAllSessions))
+	mux.HandleFunc("PUT /api/users", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerUserUpdate))
+	mux.HandleFunc("PATCH /api/users", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerUserPatch))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.requireScope(auth.ScopeChirpsWrite, apiCfg.handlerDeleteChirp))
+	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUpgradeUser)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerFollowUser))
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerUnfollowUser))
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerListFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerListFollowing)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerBlockUser))
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.requireScope(auth.ScopeAccountWrite, apiCfg.handlerUnblockUser))
+	mux.HandleFunc("GET /api/timeline", apiCfg.requireScope(auth.ScopeChirpsRead, apiCfg.handlerTimeline))
+	mux.HandleFunc("GET /api/notifications", apiCfg.requireScope(auth.ScopeChirpsRead, apiCfg.handlerListNotifications))
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.requireAnyScope([]string{auth.ScopeChirpsWrite, auth.ScopeAccountWrite}, apiCfg.handlerMarkNotificationsRead))
+	mux.HandleFunc("GET /api/search/chirps", apiCfg.scopeIfAuthenticated(auth.ScopeChirpsRead, apiCfg.handlerSearchChirps))
+	mux.HandleFunc("GET /api/hashtags/trending", apiCfg.handlerTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.scopeIfAuthenticated(auth.ScopeChirpsRead, apiCfg.handlerHashtagChirps))
+
+	return mux
+}
+
 func main() {
 	godotenv.Load()
+	platform := os.Getenv("PLATFORM")
+
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var store database.Store
 	dbURL := os.Getenv("DB_URL")
 	if dbURL == "" {
-		log.Fatal("DB_URL must be set")
+		if platform != "dev" {
+			log.Fatal("DB_URL must be set")
+		}
+		log.Println("DB_URL not set, using in-memory store")
+		store = memstore.New()
+	} else {
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			log.Fatalf("database failed to open: %s", err)
+		}
+		store = database.New(db)
 	}
-	db, err := sql.Open("postgres", dbURL)
+
+	keys, err := newKeyring(store, platform)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := keys.Load(context.Background()); err != nil {
+		log.Fatalf("loading signing keys: %s", err)
+	}
+
+	policy, err := moderation.ParsePolicy(cmp.Or(os.Getenv("MODERATION_POLICY"), "replace"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var words moderation.WordStore = dbWordStore{db: store}
+	if path := os.Getenv("BANNED_WORDS_FILE"); path != "" {
+		words = &moderation.FileWordStore{Path: path}
+	}
+	bannedWords := moderation.NewWordList(words, policy)
+	if err := bannedWords.Load(context.Background()); err != nil {
+		log.Fatalf("loading banned words: %s", err)
+	}
+
+	passwordParams, err := newPasswordParams()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordPolicy, err := newPasswordPolicy()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logins, err := newLoginLimiter(store)
 	if err != nil {
-		log.Fatalf("database failed to open: %s", err)
+		log.Fatal(err)
 	}
-	dbQueries := database.New(db)
+
+	mail, err := newMailer(platform)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if mail == nil {
+		log.Println("SMTP_ADDR and MAIL_FILE not set, password reset is off")
+	}
+
 	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             dbQueries,
-		platform:       os.Getenv("PLATFORM"),
-		secret:         os.Getenv("SECRET"),
-		polka_key:      os.Getenv("POLKA_KEY"),
+		fileserverHits:        atomic.Int32{},
+		db:                    store,
+		platform:              platform,
+		keys:                  keys,
+		tokenVersions:         newTokenVersionCache(store),
+		polka_key:             os.Getenv("POLKA_KEY"),
+		admin_key:             os.Getenv("ADMIN_KEY"),
+		trending:              newTrendingHashtags(store),
+		moderator:             bannedWords,
+		bannedWords:           bannedWords,
+		mailer:                mail,
+		passwordResetURL:      os.Getenv("PASSWORD_RESET_URL"),
+		emailVerificationURL:  os.Getenv("EMAIL_VERIFICATION_URL"),
+		verifiedEmailRequired: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+		passwordParams:        passwordParams,
+		passwordPolicy:        passwordPolicy,
+		logins:                logins,
+		now:                   time.Now,
 	}
+	go apiCfg.trending.run(context.Background(), trendingRefreshInterval)
+	go apiCfg.keys.Run(context.Background(), signingKeyReloadInterval)
 
 	const filepathRoot = "."
 	const port = "8080"
-	mux := http.NewServeMux()
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
-	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))))
-	mux.HandleFunc("GET /api/healthz", handlerReadiness)
-	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpById)
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUserUpdate)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUpgradeUser)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: apiCfg.routes(filepathRoot),
 	}
 
 	log.Printf("Serving files from %s on port: %s\n", filepathRoot, port)
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/lockout"
+	"github.com/mrbaker1917/chirpy/internal/memstore"
+	"github.com/mrbaker1917/chirpy/internal/moderation"
+)
+
+// newTestServer returns the API wired to an in-memory store, with the same
+// routes main registers.
+func newTestServer(t *testing.T) (*apiConfig, *httptest.Server) {
+	t.Helper()
+	db := memstore.New()
+	bannedWords := moderation.NewWordList(dbWordStore{db: db}, moderation.PolicyReplace)
+	if err := bannedWords.Load(context.Background()); err != nil {
+		t.Fatalf("loading banned words: %v", err)
+	}
+	cipher, err := auth.NewKeyCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("creating key cipher: %v", err)
+	}
+	keys, err := auth.NewKeyring(dbKeyStore{db: db, cipher: cipher}, auth.AlgEdDSA, defaultKeyOverlap)
+	if err != nil {
+		t.Fatalf("creating keyring: %v", err)
+	}
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatalf("loading signing keys: %v", err)
+	}
+	apiCfg := &apiConfig{
+		db:            db,
+		platform:      "dev",
+		keys:          keys,
+		tokenVersions: newTokenVersionCache(db),
+		polka_key:     "test-polka-key",
+		admin_key:     "test-admin-key",
+		trending:      newTrendingHashtags(db),
+		moderator:     bannedWords,
+		bannedWords:   bannedWords,
+		mailer:        &testMailer{},
+		// Far cheaper than a real server's, to keep the tests quick.
+		passwordParams: auth.PasswordParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		// Lax enough for the short passwords the tests use.
+		passwordPolicy: auth.PasswordPolicy{MinLength: 5},
+		logins:         lockout.New(lockout.NewMemoryStore()),
+		now:            time.Now,
+	}
+	// Tests move the clock by replacing apiCfg.now; failed logins follow it.
+	apiCfg.logins.Now = func() time.Time { return apiCfg.now() }
+	srv := httptest.NewServer(apiCfg.routes("."))
+	t.Cleanup(srv.Close)
+	return apiCfg, srv
+}
+
+// doJSON sends body as JSON with an optional Authorization header and decodes
+// the response into out when out is non-nil.
+func doJSON(t *testing.T, srv *httptest.Server, method, path, authorization string, body, out interface{}) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, srv.URL+path, &buf)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decoding %s %s response: %v", method, path, err)
+		}
+	}
+	return resp
+}
+
+type loginResponse struct {
+	User
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// createAndLogin registers a user and returns their login response.
+func createAndLogin(t *testing.T, srv *httptest.Server, email, password string) loginResponse {
+	t.Helper()
+	creds := map[string]string{"email": email, "password": password}
+	if resp := doJSON(t, srv, "POST", "/api/users", "", creds, nil); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /api/users status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	var login loginResponse
+	if resp := doJSON(t, srv, "POST", "/api/login", "", creds, &login); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /api/login status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	return login
+}
+
+func TestLoginFlow(t *testing.T) {
+	_, srv := newTestServer(t)
+	login := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantCode int
+	}{
+		{name: "Wrong password", email: "walt@example.com", password: "wrongpass", wantCode: 401},
+		{name: "Unknown email", email: "jesse@example.com", password: "04234", wantCode: 401},
+		{name: "Correct credentials", email: "walt@example.com", password: "04234", wantCode: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": tt.email, "password": tt.password}, nil)
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("POST /api/login status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+		})
+	}
+
+	var refreshed struct {
+		Token string `json:"token"`
+	}
+	resp := doJSON(t, srv, "POST", "/api/refresh", "Bearer "+login.RefreshToken, nil, &refreshed)
+	if resp.StatusCode != http.StatusOK || refreshed.Token == "" {
+		t.Fatalf("POST /api/refresh status = %d, token = %q", resp.StatusCode, refreshed.Token)
+	}
+
+	if resp := doJSON(t, srv, "POST", "/api/revoke", "Bearer "+login.RefreshToken, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST /api/revoke status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	if resp := doJSON(t, srv, "POST", "/api/refresh", "Bearer "+login.RefreshToken, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after revoke status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestChirpLifecycle(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	jesse := createAndLogin(t, srv, "jesse@example.com", "12345")
+
+	var chirp ChirpResponse
+	resp := doJSON(t, srv, "POST", "/api/chirps", "Bearer "+walt.Token, map[string]string{"body": "I am the one who Kerfuffle"}, &chirp)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /api/chirps status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	if chirp.Body != "I am the one who ****" {
+		t.Errorf("chirp body = %q, want profanity replaced", chirp.Body)
+	}
+
+	if resp := doJSON(t, srv, "POST", "/api/chirps", "", map[string]string{"body": "no token"}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /api/chirps without token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	var got ChirpResponse
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), "", nil, &got)
+	if got.ID != chirp.ID || got.UserID != walt.ID {
+		t.Errorf("GET /api/chirps/{chirpID} = %+v, want %+v", got, chirp)
+	}
+
+	if resp := doJSON(t, srv, "DELETE", "/api/chirps/"+chirp.ID.String(), "Bearer "+jesse.Token, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("DELETE by non-author status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if resp := doJSON(t, srv, "DELETE", "/api/chirps/"+chirp.ID.String(), "Bearer "+walt.Token, nil, nil); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE by author status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+
+	"github.com/mrbaker1917/chirpy/internal/database"
+	"github.com/mrbaker1917/chirpy/internal/moderation"
+)
+
+// dbWordStore keeps the banned word list in the banned_words table.
+type dbWordStore struct {
+	db database.Store
+}
+
+var _ moderation.WordStore = dbWordStore{}
+
+func (s dbWordStore) Load(ctx context.Context) ([]string, error) {
+	return s.db.ListBannedWords(ctx)
+}
+
+func (s dbWordStore) Add(ctx context.Context, word string) error {
+	return s.db.AddBannedWord(ctx, word)
+}
+
+func (s dbWordStore) Remove(ctx context.Context, word string) error {
+	return s.db.RemoveBannedWord(ctx, word)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+// Notification kinds, as stored in notifications.kind.
+const (
+	notificationKindMention = "mention"
+	notificationKindReply   = "reply"
+	notificationKindLike    = "like"
+	notificationKindFollow  = "follow"
+)
+
+// notify records that actorID did something that involves userID, such as
+// liking their chirp. Nobody is notified about their own actions or by
+// someone they have blocked. Notifications are best effort: errors are
+// logged rather than returned so they never fail the action itself.
+func (apiCfg *apiConfig) notify(ctx context.Context, userID, actorID uuid.UUID, kind string, chirpID uuid.NullUUID) {
+	if userID == actorID {
+		return
+	}
+
+	blocked, err := apiCfg.db.IsBlocked(ctx, database.IsBlockedParams{
+		BlockerID: userID,
+		BlockedID: actorID,
+	})
+	if err != nil {
+		log.Printf("Error checking blocks for %s notification: %s", kind, err)
+		return
+	}
+	if blocked {
+		return
+	}
+
+	err = apiCfg.db.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  userID,
+		ActorID: actorID,
+		Kind:    kind,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		log.Printf("Error creating %s notification: %s", kind, err)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageCursor points at the (created_at, id) key of the last row a client
+// has seen. Backward cursors ask for the rows before that key instead of
+// after it, which is how "prev" links are served.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+	Backward  bool
+}
+
+// encode returns the cursor as an opaque, URL-safe string.
+func (c pageCursor) encode() string {
+	dir := "n"
+	if c.Backward {
+		dir = "p"
+	}
+	raw := fmt.Sprintf("%s|%d|%s", dir, c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errors.New("malformed cursor")
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || (parts[0] != "n" && parts[0] != "p") {
+		return pageCursor{}, errors.New("malformed cursor")
+	}
+	micros, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return pageCursor{}, errors.New("malformed cursor")
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return pageCursor{}, errors.New("malformed cursor")
+	}
+	return pageCursor{
+		CreatedAt: time.UnixMicro(micros).UTC(),
+		ID:        id,
+		Backward:  parts[0] == "p",
+	}, nil
+}
+
+// pageParams are the limit, sort and cursor query parameters shared by the
+// paginated list endpoints.
+type pageParams struct {
+	Limit  int
+	Desc   bool
+	Cursor *pageCursor
+
+	// ForwardOnly lists have a fixed order and only a query for that
+	// direction, so they never hand out prev links.
+	ForwardOnly bool
+}
+
+func parsePageParams(q url.Values, defaultDesc bool) (pageParams, error) {
+	p := pageParams{Limit: defaultPageLimit, Desc: defaultDesc}
+
+	limit, err := parsePageLimit(q)
+	if err != nil {
+		return pageParams{}, err
+	}
+	p.Limit = limit
+
+	switch q.Get("sort") {
+	case "":
+	case "asc":
+		p.Desc = false
+	case "desc":
+		p.Desc = true
+	default:
+		return pageParams{}, errors.New("sort must be asc or desc")
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		cursor, err := decodePageCursor(c)
+		if err != nil {
+			return pageParams{}, err
+		}
+		p.Cursor = &cursor
+	}
+	return p, nil
+}
+
+// parseFeedPageParams parses the limit and cursor of a ForwardOnly list.
+func parseFeedPageParams(q url.Values) (pageParams, error) {
+	p := pageParams{ForwardOnly: true}
+
+	limit, err := parsePageLimit(q)
+	if err != nil {
+		return pageParams{}, err
+	}
+	p.Limit = limit
+
+	if c := q.Get("cursor"); c != "" {
+		cursor, err := decodePageCursor(c)
+		if err != nil || cursor.Backward {
+			return pageParams{}, errors.New("malformed cursor")
+		}
+		p.Cursor = &cursor
+	}
+	return p, nil
+}
+
+func parsePageLimit(q url.Values) (int, error) {
+	l := q.Get("limit")
+	if l == "" {
+		return defaultPageLimit, nil
+	}
+	n, err := strconv.Atoi(l)
+	if err != nil || n < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	return min(n, maxPageLimit), nil
+}
+
+// cursorArgs returns the cursor as the nullable query arguments the list
+// queries take.
+func (p pageParams) cursorArgs() (sql.NullTime, uuid.NullUUID) {
+	if p.Cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}, uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// queryDesc reports which direction the page has to be read from the
+// database in. Backward cursors read against the requested sort order and
+// the handler reverses the rows afterwards.
+func (p pageParams) queryDesc() bool {
+	if p.Cursor != nil && p.Cursor.Backward {
+		return !p.Desc
+	}
+	return p.Desc
+}
+
+// finishPage turns rows read with a limit of p.Limit+1 into the page to
+// return: it drops the lookahead row, restores display order for backward
+// cursors and sets an RFC 8288 Link header with next and prev cursors.
+func finishPage[T any](w http.ResponseWriter, r *http.Request, p pageParams, rows []T, key func(T) pageCursor) []T {
+	hasMore := len(rows) > p.Limit
+	if hasMore {
+		rows = rows[:p.Limit]
+	}
+
+	backward := p.Cursor != nil && p.Cursor.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows
+	}
+
+	hasNext, hasPrev := hasMore, p.Cursor != nil && !p.ForwardOnly
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	var links []string
+	if hasNext {
+		last := key(rows[len(rows)-1])
+		last.Backward = false
+		links = append(links, pageLink(r, last, "next"))
+	}
+	if hasPrev {
+		first := key(rows[0])
+		first.Backward = true
+		links = append(links, pageLink(r, first, "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	return rows
+}
+
+func pageLink(r *http.Request, c pageCursor, rel string) string {
+	q := r.URL.Query()
+	q.Set("cursor", c.encode())
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+}
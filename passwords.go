@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+// newPasswordParams returns the argon2id parameters the environment
+// configures: ARGON2_MEMORY in KiB, ARGON2_ITERATIONS and
+// ARGON2_PARALLELISM, each defaulting to auth.DefaultPasswordParams.
+func newPasswordParams() (auth.PasswordParams, error) {
+	p := auth.DefaultPasswordParams
+	for _, v := range []struct {
+		name string
+		dst  *uint32
+	}{
+		{"ARGON2_MEMORY", &p.Memory},
+		{"ARGON2_ITERATIONS", &p.Iterations},
+	} {
+		if s := os.Getenv(v.name); s != "" {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return p, fmt.Errorf("%s: %w", v.name, err)
+			}
+			*v.dst = uint32(n)
+		}
+	}
+	if s := os.Getenv("ARGON2_PARALLELISM"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return p, fmt.Errorf("ARGON2_PARALLELISM: %w", err)
+		}
+		p.Parallelism = uint8(n)
+	}
+	return p, p.Validate()
+}
+
+// newPasswordPolicy returns the policy the environment configures for new
+// passwords: PASSWORD_MIN_LENGTH and PASSWORD_MIN_ENTROPY (in bits) default
+// to auth.DefaultPasswordPolicy, and BREACHED_PASSWORDS_FILE, if set, is an
+// auth.BreachedFile of leaked passwords to refuse.
+func newPasswordPolicy() (auth.PasswordPolicy, error) {
+	p := auth.DefaultPasswordPolicy
+	if s := os.Getenv("PASSWORD_MIN_LENGTH"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return p, fmt.Errorf("PASSWORD_MIN_LENGTH: %w", err)
+		}
+		p.MinLength = n
+	}
+	if s := os.Getenv("PASSWORD_MIN_ENTROPY"); s != "" {
+		bits, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return p, fmt.Errorf("PASSWORD_MIN_ENTROPY: %w", err)
+		}
+		p.MinEntropy = bits
+	}
+	if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return p, fmt.Errorf("BREACHED_PASSWORDS_FILE: %w", err)
+		}
+		p.Breached = auth.BreachedFile{Path: path}
+	}
+	return p, nil
+}
+
+// checkPassword checks a new password against the password policy, with
+// userInputs the details of the user it is for, and responds with what is
+// wrong with it if it falls short. It reports whether the password can be
+// used.
+func (apiCfg *apiConfig) checkPassword(w http.ResponseWriter, r *http.Request, password string, userInputs ...string) bool {
+	problems, err := apiCfg.passwordPolicy.Check(r.Context(), password, userInputs...)
+	if err != nil {
+		log.Printf("Error checking password: %s", err)
+		respondWithError(w, 500, "Error checking password")
+		return false
+	}
+	if len(problems) == 0 {
+		return true
+	}
+	errs := make([]fieldError, len(problems))
+	for i, p := range problems {
+		errs[i] = fieldError{Field: "password", Code: p.Code, Message: p.Message}
+	}
+	respondWithFieldErrors(w, "The password is not allowed", errs)
+	return false
+}
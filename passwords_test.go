@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	apiCfg, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, fmt.Appendf(nil, "%X:3861493\r\n", sha1.Sum([]byte("Tr0ub4dor&3"))), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	apiCfg.passwordPolicy = auth.DefaultPasswordPolicy
+	apiCfg.passwordPolicy.Breached = auth.BreachedFile{Path: path}
+
+	type rejection struct {
+		Error  string       `json:"error"`
+		Fields []fieldError `json:"fields"`
+	}
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		body          map[string]string
+		wantCode      int
+		wantProblems  []string
+	}{
+		{name: "Signup with a short password", method: "POST", path: "/api/users", body: map[string]string{"email": "jesse@example.com", "password": "yo"}, wantCode: http.StatusBadRequest, wantProblems: []string{auth.PasswordTooShort}},
+		{name: "Signup with the user's email", method: "POST", path: "/api/users", body: map[string]string{"email": "jesse.pinkman@example.com", "password": "pinkman123"}, wantCode: http.StatusBadRequest, wantProblems: []string{auth.PasswordTooWeak}},
+		{name: "Signup with a breached password", method: "POST", path: "/api/users", body: map[string]string{"email": "jesse@example.com", "password": "Tr0ub4dor&3"}, wantCode: http.StatusBadRequest, wantProblems: []string{auth.PasswordBreached}},
+		{name: "Signup with a good password", method: "POST", path: "/api/users", body: map[string]string{"email": "jesse@example.com", "password": "n0t-in-any-l1st!"}, wantCode: http.StatusCreated},
+		{name: "Change to a weak password", method: "PUT", path: "/api/users", authorization: "Bearer " + walt.Token, body: map[string]string{"email": "walt@example.com", "password": "password1"}, wantCode: http.StatusBadRequest, wantProblems: []string{auth.PasswordTooWeak}},
+		{name: "Keep an old password", method: "PUT", path: "/api/users", authorization: "Bearer " + walt.Token, body: map[string]string{"email": "walt@example.com", "password": "04234"}, wantCode: http.StatusOK},
+		{name: "Change to a good password", method: "PUT", path: "/api/users", authorization: "Bearer " + walt.Token, body: map[string]string{"email": "walt@example.com", "password": "blue crystal meth"}, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got rejection
+			resp := doJSON(t, srv, tt.method, tt.path, tt.authorization, tt.body, &got)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusBadRequest {
+				return
+			}
+			var problems []string
+			for _, f := range got.Fields {
+				if f.Field != "password" || f.Message == "" {
+					t.Errorf("%s %s field error = %+v, want a message about the password", tt.method, tt.path, f)
+				}
+				problems = append(problems, f.Code)
+			}
+			if !slices.Equal(problems, tt.wantProblems) {
+				t.Errorf("%s %s problems = %v, want %v", tt.method, tt.path, problems, tt.wantProblems)
+			}
+		})
+	}
+}
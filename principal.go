@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+var errNoCredentials = errors.New("no access token or API key")
+
+type principalKey struct{}
+
+// withPrincipal returns a copy of ctx that carries who the request is made
+// by: the access token it was made with, or what its API key stands for.
+func withPrincipal(ctx context.Context, tok auth.AccessToken) context.Context {
+	return context.WithValue(ctx, principalKey{}, tok)
+}
+
+// principalFrom returns the principal the authentication middleware put in
+// ctx, if the request had one.
+func principalFrom(ctx context.Context) (auth.AccessToken, bool) {
+	tok, ok := ctx.Value(principalKey{}).(auth.AccessToken)
+	return tok, ok
+}
+
+// requestPrincipal is principalFrom for handlers behind requireScope, which
+// never run without a principal.
+func requestPrincipal(r *http.Request) auth.AccessToken {
+	tok, ok := principalFrom(r.Context())
+	if !ok {
+		panic(fmt.Sprintf("%s %s has no principal; is it registered with requireScope?", r.Method, r.URL.Path))
+	}
+	return tok
+}
+
+// authenticate checks the access token or user API key in the request's
+// Authorization header. It returns errNoCredentials if there is neither.
+func (apiCfg *apiConfig) authenticate(r *http.Request) (auth.AccessToken, error) {
+	token, err := auth.GetCredential(r.Header)
+	if err != nil {
+		return auth.AccessToken{}, errNoCredentials
+	}
+	return apiCfg.parseAccessToken(r.Context(), token)
+}
+
+// requireScope lets a request through to next only if it carries a valid
+// access token or API key with scope, which next can get back with
+// requestPrincipal.
+func (apiCfg *apiConfig) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return apiCfg.requireAnyScope([]string{scope}, next)
+}
+
+// requireAnyScope is requireScope for routes that any one of scopes is
+// enough for.
+func (apiCfg *apiConfig) requireAnyScope(scopes []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tok, err := apiCfg.authenticate(r)
+		if err != nil {
+			respondUnauthorized(w, err)
+			return
+		}
+		if !slices.ContainsFunc(scopes, tok.HasScope) {
+			respondInsufficientScope(w, scopes...)
+			return
+		}
+		next(w, r.WithContext(withPrincipal(r.Context(), tok)))
+	}
+}
+
+// scopeIfAuthenticated is requireScope for routes anyone may use: requests
+// without a valid access token go through as anonymous, but a token that
+// lacks scope cannot be used to act as its user.
+func (apiCfg *apiConfig) scopeIfAuthenticated(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tok, err := apiCfg.authenticate(r)
+		if err != nil {
+			next(w, r)
+			return
+		}
+		if !tok.HasScope(scope) {
+			respondInsufficientScope(w, scope)
+			return
+		}
+		next(w, r.WithContext(withPrincipal(r.Context(), tok)))
+	}
+}
+
+// requireAdmin lets a request through to next only if it carries the
+// ADMIN_KEY as "Authorization: ApiKey <key>", or an access token or user API
+// key with the admin scope.
+func (apiCfg *apiConfig) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if apiCfg.admin_key == "" {
+			respondWithProblem(w, http.StatusForbidden, "The admin API is disabled")
+			return
+		}
+		apiKey, err := auth.GetAPIKey(r.Header)
+		if err == nil && subtle.ConstantTimeCompare([]byte(apiKey), []byte(apiCfg.admin_key)) == 1 {
+			next(w, r)
+			return
+		}
+		apiCfg.requireScope(auth.ScopeAdmin, next)(w, r)
+	}
+}
+
+// problem is an RFC 9457 problem details response.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func respondWithProblem(w http.ResponseWriter, status int, detail string) {
+	dat, err := json.Marshal(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+	if err != nil {
+		log.Printf("Error marshalling JSON: %s", err)
+		w.WriteHeader(500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	w.Write(dat)
+}
+
+// respondUnauthorized rejects a request whose credentials are missing or
+// not valid, with the challenge RFC 6750 asks for.
+func respondUnauthorized(w http.ResponseWriter, err error) {
+	if errors.Is(err, errNoCredentials) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="chirpy"`)
+		respondWithProblem(w, http.StatusUnauthorized, "An access token or API key is required")
+		return
+	}
+	log.Printf("Error validating access token %s", err)
+	w.Header().Set("WWW-Authenticate", `Bearer realm="chirpy", error="invalid_token"`)
+	respondWithProblem(w, http.StatusUnauthorized, "The access token or API key is not valid")
+}
+
+// respondInsufficientScope rejects a token that is valid but not allowed to
+// do what was asked, saying which scopes would do as RFC 6750 describes.
+func respondInsufficientScope(w http.ResponseWriter, scopes ...string) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="chirpy", error="insufficient_scope", scope=%q`, strings.Join(scopes, " ")))
+	respondWithProblem(w, http.StatusForbidden, fmt.Sprintf("This token does not have the %s scope", strings.Join(scopes, " or ")))
+}
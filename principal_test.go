@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrbaker1917/chirpy/internal/auth"
+)
+
+func TestAuthProblemResponses(t *testing.T) {
+	_, srv := newTestServer(t)
+	walt := createAndLogin(t, srv, "walt@example.com", "04234")
+	var reader TokenResponse
+	doJSON(t, srv, "POST", "/api/tokens", "Bearer "+walt.Token, map[string]string{"scope": "chirps:read"}, &reader)
+	var account TokenResponse
+	doJSON(t, srv, "POST", "/api/tokens", "Bearer "+walt.Token, map[string]string{"scope": "account:write"}, &account)
+
+	tests := []struct {
+		name          string
+		authorization string
+		method        string
+		path          string
+		body          map[string]string
+		wantCode      int
+		wantChallenge string
+	}{
+		{name: "No credentials", authorization: "", method: "DELETE", path: "/api/chirps/" + walt.ID.String(), wantCode: http.StatusUnauthorized, wantChallenge: `Bearer realm="chirpy"`},
+		{name: "Invalid token", authorization: "Bearer nope", method: "PUT", path: "/api/users", wantCode: http.StatusUnauthorized, wantChallenge: `error="invalid_token"`},
+		{name: "Invalid API key", authorization: "ApiKey chirpy_nope", method: "POST", path: "/api/chirps", wantCode: http.StatusUnauthorized, wantChallenge: `error="invalid_token"`},
+		{name: "Missing scope", authorization: "Bearer " + reader.Token, method: "POST", path: "/api/chirps", wantCode: http.StatusForbidden, wantChallenge: `error="insufficient_scope"`},
+		{name: "Admin route with a user token", authorization: "Bearer " + walt.Token, method: "GET", path: "/admin/keys", wantCode: http.StatusForbidden, wantChallenge: `scope="admin"`},
+		{name: "Admin route with a bad ApiKey", authorization: "ApiKey nope", method: "GET", path: "/admin/keys", wantCode: http.StatusUnauthorized, wantChallenge: `error="invalid_token"`},
+		{name: "Wrong password", method: "POST", path: "/api/login", body: map[string]string{"email": "walt@example.com", "password": "nope"}, wantCode: http.StatusUnauthorized},
+		{name: "Bad refresh token", authorization: "Bearer nope", method: "POST", path: "/api/refresh", wantCode: http.StatusUnauthorized},
+		{name: "Bad reset token", method: "POST", path: "/api/password/reset", body: map[string]string{"token": "nope", "password": "heisenberg"}, wantCode: http.StatusUnauthorized},
+		{name: "Wrong current password", authorization: "Bearer " + walt.Token, method: "PATCH", path: "/api/users", body: map[string]string{"password": "fghij", "current_password": "nope"}, wantCode: http.StatusForbidden},
+		{name: "Key with more scopes than its token", authorization: "Bearer " + account.Token, method: "POST", path: "/api/keys", body: map[string]string{"name": "bot", "scope": "chirps:write"}, wantCode: http.StatusForbidden},
+		{name: "Token with more scopes than the one minting it", authorization: "Bearer " + account.Token, method: "POST", path: "/api/tokens", body: map[string]string{"scope": "chirps:write"}, wantCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body problem
+			if tt.body == nil {
+				tt.body = map[string]string{}
+			}
+			resp := doJSON(t, srv, tt.method, tt.path, tt.authorization, tt.body, &body)
+			if resp.StatusCode != tt.wantCode || body.Status != tt.wantCode || body.Title != http.StatusText(tt.wantCode) {
+				t.Errorf("%s %s = %d %+v, want a %d problem", tt.method, tt.path, resp.StatusCode, body, tt.wantCode)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("%s %s Content-Type = %q, want application/problem+json", tt.method, tt.path, ct)
+			}
+			if challenge := resp.Header.Get("WWW-Authenticate"); !strings.Contains(challenge, tt.wantChallenge) {
+				t.Errorf("%s %s WWW-Authenticate = %q, want it to contain %q", tt.method, tt.path, challenge, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestRequestPrincipal(t *testing.T) {
+	apiCfg, _ := newTestServer(t)
+	tok := auth.AccessToken{Scopes: []string{auth.ScopeChirpsRead}}
+
+	handler := apiCfg.scopeIfAuthenticated(auth.ScopeChirpsRead, func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := principalFrom(r.Context()); ok {
+			t.Errorf("principalFrom() for an anonymous request ok = true, want false")
+		}
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/chirps", nil))
+
+	r := httptest.NewRequest("GET", "/api/timeline", nil)
+	got := requestPrincipal(r.WithContext(withPrincipal(r.Context(), tok)))
+	if !got.HasScope(auth.ScopeChirpsRead) {
+		t.Errorf("requestPrincipal() = %+v, want %+v", got, tok)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("requestPrincipal() without a principal did not panic")
+		}
+	}()
+	requestPrincipal(r)
+}
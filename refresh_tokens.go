@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrbaker1917/chirpy/internal/auth"
+	"github.com/mrbaker1917/chirpy/internal/database"
+)
+
+const (
+	refreshTokenTTL = 60 * 24 * time.Hour
+	// maxUserAgentLength caps how much of the User-Agent header is kept
+	// with a session.
+	maxUserAgentLength = 256
+)
+
+// issueRefreshToken creates a new refresh token for userID and stores its
+// hash along with the client that asked for it. A login starts a new family;
+// every rotation after that stays in the same family and records the hash of
+// the token it replaced as its parent.
+func (apiCfg *apiConfig) issueRefreshToken(r *http.Request, userID, familyID uuid.UUID, parentHash sql.NullString) (string, error) {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = apiCfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		TokenHash:       auth.HashRefreshToken(token),
+		UserID:          userID,
+		ExpiresAt:       time.Now().Add(refreshTokenTTL),
+		FamilyID:        familyID,
+		ParentTokenHash: parentHash,
+		UserAgent:       clientUserAgent(r),
+		IpAddress:       clientIP(r),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func clientUserAgent(r *http.Request) string {
+	ua := r.UserAgent()
+	if len(ua) > maxUserAgentLength {
+		ua = strings.ToValidUTF8(ua[:maxUserAgentLength], "")
+	}
+	return ua
+}
+
+// clientIP is the address the request came from. Chirpy does not trust
+// X-Forwarded-For, so behind a proxy this is the proxy's address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// detectRefreshTokenReuse is called with the hash of a refresh token that
+// could not be consumed. Expired and unknown tokens are simply rejected, but a token that
+// was already rotated or revoked being presented again means it has leaked,
+// so every token in its family is revoked and the owner has to log in again.
+// A token rotated in the last few seconds whose replacement is still unused
+// is rejected without revoking anything.
+func (apiCfg *apiConfig) detectRefreshTokenReuse(ctx context.Context, tokenHash string) {
+	rt, err := apiCfg.db.GetRefreshToken(ctx, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		log.Printf("Could not look up refresh token: %s", err)
+		return
+	}
+	if !rt.RevokedAt.Valid {
+		return
+	}
+	// A token that was rotated moments ago and whose replacement is still
+	// unused is most likely a second tab refreshing at the same time.
+	_, err = apiCfg.db.GetRefreshTokenReplacement(ctx, tokenHash)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Could not look up replacement refresh token: %s", err)
+		return
+	}
+
+	log.Printf("SECURITY: revoked refresh token reused for user %s, revoking token family %s", rt.UserID, rt.FamilyID)
+	if err := apiCfg.db.RevokeRefreshTokenFamily(ctx, rt.FamilyID); err != nil {
+		log.Printf("Could not revoke refresh token family %s: %s", rt.FamilyID, err)
+	}
+}
@@ -0,0 +1,41 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scope, created_at, last_used_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    NOW(),
+    NULL
+)
+RETURNING *;
+
+-- name: DeleteApiKey :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteUserApiKeys :exec
+-- Deletes every key a user has, when they reset their password or sign out
+-- everywhere.
+DELETE FROM api_keys
+WHERE user_id = $1;
+
+-- name: GetApiKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1;
+
+-- name: ListApiKeys :many
+-- Lists a user's keys, newest first.
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: TouchApiKey :exec
+-- Records that a key was used. It only writes once a minute per key, so
+-- that a busy bot does not cost a write on every request.
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1
+    AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
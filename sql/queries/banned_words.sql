@@ -0,0 +1,12 @@
+-- name: AddBannedWord :exec
+INSERT INTO banned_words (word, created_at)
+VALUES ($1, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: ListBannedWords :many
+SELECT word FROM banned_words
+ORDER BY word;
+
+-- name: RemoveBannedWord :exec
+DELETE FROM banned_words
+WHERE word = $1;
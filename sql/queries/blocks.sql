@@ -0,0 +1,29 @@
+-- name: BlockUser :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocker_id = $1 AND blocked_id = $2
+);
+
+-- name: DeleteBlockedRechirps :exec
+DELETE FROM chirps
+WHERE kind = 'rechirp' AND user_id = sqlc.arg('blocked_id')
+    AND ref_chirp_id IN (SELECT original.id FROM chirps AS original WHERE original.user_id = sqlc.arg('blocker_id'));
+
+-- name: DetachBlockedQuotes :exec
+UPDATE chirps
+SET ref_chirp_id = NULL, updated_at = NOW()
+WHERE kind = 'quote' AND user_id = sqlc.arg('blocked_id')
+    AND ref_chirp_id IN (SELECT original.id FROM chirps AS original WHERE original.user_id = sqlc.arg('blocker_id'));
@@ -0,0 +1,27 @@
+-- name: AddChirpHashtags :exec
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+SELECT chirps.id, tags.tag, chirps.created_at
+FROM chirps, UNNEST(sqlc.arg('tags')::text[]) AS tags(tag)
+WHERE chirps.id = sqlc.arg('chirp_id')
+ON CONFLICT DO NOTHING;
+
+-- name: ListChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+WHERE chirp_hashtags.tag = sqlc.arg('tag')
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingHashtags :many
+-- Scores every tag used since the start of the window by its uses, each
+-- worth half as much for every half life that has passed since it was made.
+SELECT tag,
+    COUNT(*) AS chirp_count,
+    SUM(POWER(0.5, EXTRACT(EPOCH FROM (NOW() - created_at)) / sqlc.arg('half_life_seconds')::float8))::float8 AS score
+FROM chirp_hashtags
+WHERE created_at >= sqlc.arg('since')::timestamp
+GROUP BY tag
+ORDER BY score DESC, tag
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,14 @@
+-- name: AddChirpMentions :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+SELECT sqlc.arg('chirp_id')::uuid, m.user_id, m.start_offset, m.end_offset
+FROM UNNEST(
+    sqlc.arg('user_ids')::uuid[],
+    sqlc.arg('start_offsets')::int[],
+    sqlc.arg('end_offsets')::int[]
+) AS m(user_id, start_offset, end_offset)
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpMentions :many
+SELECT * FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, start_offset;
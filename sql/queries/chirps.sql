@@ -1,27 +1,101 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, kind, ref_chirp_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
--- name: GetChirps :many
+-- name: ListChirpsAsc :many
 SELECT * FROM chirps
-ORDER BY created_at;
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
 
--- name: GetChirpsByAuthor :many
+-- name: ListChirpsDesc :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at;
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListReplies :many
+SELECT * FROM chirps
+WHERE in_reply_to = sqlc.arg('chirp_id')
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.*, 1 AS depth FROM chirps AS parent
+    WHERE parent.id = (SELECT c.in_reply_to FROM chirps AS c WHERE c.id = $1)
+    UNION ALL
+    SELECT parent.*, a.depth + 1 FROM chirps AS parent
+    JOIN ancestors AS a ON parent.id = a.in_reply_to
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to FROM ancestors
+ORDER BY depth DESC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT reply.*, 1 AS depth FROM chirps AS reply
+    WHERE reply.in_reply_to = sqlc.arg('chirp_id')
+    UNION ALL
+    SELECT reply.*, d.depth + 1 FROM chirps AS reply
+    JOIN descendants AS d ON reply.in_reply_to = d.id
+    WHERE d.depth < sqlc.arg('max_depth')::int
+)
+SELECT id, created_at, updated_at, body, user_id, in_reply_to FROM descendants
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: GetChirpById :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpsByIds :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: GetRechirp :one
+SELECT * FROM chirps
+WHERE kind = 'rechirp' AND user_id = $1 AND ref_chirp_id = $2;
+
+-- name: GetRechirpCounts :many
+SELECT ref_chirp_id, COUNT(*) AS rechirp_count FROM chirps
+WHERE kind = 'rechirp' AND ref_chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY ref_chirp_id;
+
+-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE kind = 'rechirp' AND user_id = $1 AND ref_chirp_id = $2;
+
 -- name: DeleteChirpById :exec
+-- Rechirps have nothing of their own to show, so they go with the chirp
+-- they repost.
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 OR (kind = 'rechirp' AND ref_chirp_id = $1);
+
+-- name: SearchChirps :many
+-- The query uses websearch_to_tsquery syntax: "quoted phrases", -excluded
+-- words and OR.
+SELECT sqlc.embed(chirps),
+    ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query')))::float8 AS rank
+FROM chirps
+WHERE to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', sqlc.arg('query'))
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since'))
+    AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until'))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
@@ -0,0 +1,14 @@
+-- name: CreateEmailVerification :exec
+INSERT INTO email_verifications (token_hash, user_id, email, created_at, expires_at)
+VALUES ($1, $2, $3, NOW(), $4);
+
+-- name: DeleteEmailVerifications :exec
+DELETE FROM email_verifications
+WHERE user_id = $1;
+
+-- name: UseEmailVerification :one
+-- Deletes a verification token and returns it, if it has not expired, so
+-- that it can only be used once.
+DELETE FROM email_verifications
+WHERE token_hash = sqlc.arg('token_hash') AND expires_at > sqlc.arg('now')
+RETURNING *;
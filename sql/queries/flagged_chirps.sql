@@ -0,0 +1,16 @@
+-- name: FlagChirp :exec
+INSERT INTO flagged_chirps (chirp_id, words, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (chirp_id) DO UPDATE SET words = EXCLUDED.words;
+
+-- name: ListFlaggedChirps :many
+SELECT sqlc.embed(chirps), flagged_chirps.words, flagged_chirps.created_at AS flagged_at FROM flagged_chirps
+JOIN chirps ON chirps.id = flagged_chirps.chirp_id
+WHERE sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (flagged_chirps.created_at, flagged_chirps.chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+ORDER BY flagged_chirps.created_at DESC, flagged_chirps.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UnflagChirp :exec
+DELETE FROM flagged_chirps
+WHERE chirp_id = $1;
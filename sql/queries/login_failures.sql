@@ -0,0 +1,24 @@
+-- name: DeleteLoginFailures :exec
+DELETE FROM login_failures
+WHERE key = $1;
+
+-- name: DeleteStaleLoginFailures :exec
+DELETE FROM login_failures
+WHERE last_failed_at <= $1;
+
+-- name: GetLoginFailures :one
+SELECT * FROM login_failures
+WHERE key = $1;
+
+-- name: RecordLoginFailure :one
+-- Counts a failure for a key, starting again from one if its last failure
+-- was no later than forget_before.
+INSERT INTO login_failures (key, failures, last_failed_at)
+VALUES (sqlc.arg('key'), 1, sqlc.arg('at'))
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_failures.last_failed_at <= sqlc.arg('forget_before') THEN 1
+        ELSE login_failures.failures + 1
+    END,
+    last_failed_at = EXCLUDED.last_failed_at
+RETURNING *;
@@ -0,0 +1,72 @@
+-- name: AdvanceUserTotpStep :execrows
+-- Records that the code for a time step was used, unless that step or a
+-- later one already was.
+UPDATE user_totp
+SET last_used_step = sqlc.arg('step')
+WHERE user_id = sqlc.arg('user_id')
+    AND confirmed_at IS NOT NULL
+    AND last_used_step < sqlc.arg('step');
+
+-- name: AttemptMfaChallenge :one
+-- Counts an attempt at a challenge and returns it, if it has not expired or
+-- run out of attempts.
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = sqlc.arg('token_hash')
+    AND expires_at > sqlc.arg('now')
+    AND attempts < sqlc.arg('max_attempts')
+RETURNING *;
+
+-- name: ConfirmUserTotp :execrows
+UPDATE user_totp
+SET confirmed_at = NOW(), last_used_step = sqlc.arg('step')
+WHERE user_id = sqlc.arg('user_id') AND confirmed_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CreateMfaChallenge :exec
+INSERT INTO mfa_challenges (token_hash, user_id, created_at, expires_at, attempts)
+VALUES ($1, $2, NOW(), $3, 0);
+
+-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at, used_at)
+SELECT sqlc.arg('user_id'), code_hash, NOW(), NULL
+FROM UNNEST(sqlc.arg('code_hashes')::text[]) AS codes(code_hash);
+
+-- name: DeleteMfaChallenge :exec
+DELETE FROM mfa_challenges
+WHERE token_hash = $1;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: DeleteUserMfaChallenges :exec
+-- Deletes a user's outstanding challenges, so that a password reset also
+-- cancels logins that were waiting for a code.
+DELETE FROM mfa_challenges
+WHERE user_id = $1;
+
+-- name: DeleteUserTotp :exec
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+-- name: GetUserTotp :one
+SELECT * FROM user_totp
+WHERE user_id = $1;
+
+-- name: SetPendingUserTotp :execrows
+-- Stores a new secret waiting to be confirmed, replacing any earlier one
+-- that was not. A confirmed secret is left alone.
+INSERT INTO user_totp (user_id, secret, created_at, confirmed_at, last_used_step)
+VALUES ($1, $2, NOW(), NULL, 0)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_used_step = 0
+WHERE user_totp.confirmed_at IS NULL;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
@@ -0,0 +1,33 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications (id, created_at, user_id, actor_id, kind, chirp_id, read_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    NULL
+);
+
+-- name: ListNotifications :many
+SELECT sqlc.embed(notifications), users.handle AS actor_handle FROM notifications
+JOIN users ON users.id = notifications.actor_id
+WHERE notifications.user_id = sqlc.arg('user_id')
+    AND (NOT sqlc.arg('unread_only')::bool OR notifications.read_at IS NULL)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (notifications.created_at, notifications.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+    AND read_at IS NULL
+    AND id = ANY(sqlc.arg('ids')::uuid[]);
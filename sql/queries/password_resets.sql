@@ -0,0 +1,14 @@
+-- name: CreatePasswordReset :exec
+INSERT INTO password_resets (token_hash, user_id, created_at, expires_at)
+VALUES ($1, $2, NOW(), $3);
+
+-- name: DeletePasswordResets :exec
+DELETE FROM password_resets
+WHERE user_id = $1;
+
+-- name: UsePasswordReset :one
+-- Deletes a reset token and returns it, if it has not expired, so that it
+-- can only be used once.
+DELETE FROM password_resets
+WHERE token_hash = sqlc.arg('token_hash') AND expires_at > sqlc.arg('now')
+RETURNING *;
@@ -1,25 +1,95 @@
+-- name: ConsumeRefreshToken :one
+-- Revokes a live token and returns it, so that it can be exchanged for a
+-- new one exactly once.
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE token_hash = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+RETURNING *;
+
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, 
-user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, 
+user_id, expires_at, revoked_at, family_id, parent_token_hash,
+user_agent, ip_address, last_used_at)
 VALUES (
     $1,
     NOW(),
     NOW(),
     $2,
     $3,
-    NULL
+    NULL,
+    $4,
+    $5,
+    $6,
+    $7,
+    NOW()
 )
 RETURNING *;
 
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1;
+
+-- name: GetRefreshTokenReplacement :one
+-- Returns the live token that replaced a token in the last ten seconds. A
+-- client refreshing from two tabs at once sends the old token twice, which
+-- is not a leak as long as its replacement has not been used yet.
+SELECT child.* FROM refresh_tokens AS child
+JOIN refresh_tokens AS parent
+    ON parent.token_hash = child.parent_token_hash
+WHERE parent.token_hash = $1
+    AND parent.revoked_at > NOW() - INTERVAL '10 seconds'
+    AND child.revoked_at IS NULL
+    AND child.expires_at > NOW();
+
 -- name: GetUserFromRefreshToken :one
 SELECT users.* FROM users
 JOIN refresh_tokens AS r
     ON users.id = r.user_id
-WHERE r.token = $1
+WHERE r.token_hash = $1
     AND r.revoked_at IS NULL
     AND r.expires_at > NOW();
 
+-- name: ListSessions :many
+-- A session is a token family; its live token carries the latest user
+-- agent, address and use, and its oldest token says when it signed in.
+SELECT sqlc.embed(refresh_tokens), (
+    SELECT MIN(f.created_at) FROM refresh_tokens AS f
+    WHERE f.family_id = refresh_tokens.family_id
+)::timestamp AS signed_in_at
+FROM refresh_tokens
+WHERE user_id = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+ORDER BY last_used_at DESC, family_id;
+
+-- name: RevokeAllSessions :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeOtherSessions :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1
+    AND family_id <> $2
+    AND revoked_at IS NULL;
+
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1
+    AND user_id = $2
+    AND revoked_at IS NULL
+    AND expires_at > NOW();
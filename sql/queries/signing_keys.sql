@@ -0,0 +1,28 @@
+-- name: CreateSigningKey :one
+INSERT INTO signing_keys (id, algorithm, private_key, created_at, retires_at)
+VALUES ($1, $2, $3, NOW(), NULL)
+RETURNING *;
+
+-- name: GetFirstSigningKeyCreatedAt :one
+-- Returns when the oldest key was made, even if it has retired.
+SELECT created_at FROM signing_keys
+ORDER BY created_at, id
+LIMIT 1;
+
+-- name: ListSigningKeys :many
+-- Lists the keys that have not retired yet, oldest first.
+SELECT * FROM signing_keys
+WHERE retires_at IS NULL OR retires_at > NOW()
+ORDER BY created_at, id;
+
+-- name: RetireSigningKeys :exec
+-- Sets the retirement time of every key that is still signing, except the
+-- one replacing them.
+UPDATE signing_keys
+SET retires_at = sqlc.arg('retires_at')
+WHERE retires_at IS NULL AND id <> sqlc.arg('except_id');
+
+-- name: UpdateSigningKeyPrivateKey :exec
+UPDATE signing_keys
+SET private_key = $2
+WHERE id = $1;
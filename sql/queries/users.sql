@@ -1,28 +1,85 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
 -- name: DeleteAll :exec
 DELETE FROM users;
 
+-- name: GetTokenVersion :one
+SELECT token_version FROM users
+WHERE id = $1;
+
 -- name: GetUserByEmail :one
 SELECT * FROM users
 WHERE email = $1;
 
+-- name: GetUserById :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE handle = ANY(sqlc.arg('handles')::text[]);
+
+-- name: IncrementTokenVersion :one
+UPDATE users
+SET token_version = token_version + 1
+WHERE id = $1
+RETURNING token_version;
+
+-- name: PatchUser :one
+-- Updates only the columns whose set_ flag is true, leaving the others as
+-- they are.
+UPDATE users
+SET handle = CASE WHEN sqlc.arg('set_handle')::boolean THEN sqlc.arg('handle')::text ELSE handle END,
+    hashed_password = CASE WHEN sqlc.arg('set_hashed_password')::boolean THEN sqlc.arg('hashed_password')::text ELSE hashed_password END,
+    pending_email = CASE WHEN sqlc.arg('set_pending_email')::boolean THEN sqlc.narg('pending_email')::text ELSE pending_email END,
+    display_name = CASE WHEN sqlc.arg('set_display_name')::boolean THEN sqlc.narg('display_name')::text ELSE display_name END,
+    bio = CASE WHEN sqlc.arg('set_bio')::boolean THEN sqlc.narg('bio')::text ELSE bio END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: RehashUserPassword :exec
+-- Replaces a password hash with one made with new parameters, unless the
+-- password has been changed since the old hash was read.
+UPDATE users
+SET hashed_password = sqlc.arg('new_hash')
+WHERE id = sqlc.arg('id') AND hashed_password = sqlc.arg('old_hash');
+
+-- name: SetPendingEmail :exec
+UPDATE users
+SET pending_email = $2, updated_at = NOW()
+WHERE id = $1;
+
 -- name: UpdateUser :one
 UPDATE users
 SET email = $2, hashed_password = $3
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1;
+
 -- name: UpgradeUser :exec
 UPDATE users
 SET is_chirpy_red = true
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: VerifyUserEmail :one
+-- Makes a verified address the user's email, and clears pending_email if
+-- that was the address.
+UPDATE users
+SET email = $2, verified_at = NOW(), pending_email = NULLIF(pending_email, $2), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
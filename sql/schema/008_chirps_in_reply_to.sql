@@ -0,0 +1,10 @@
+-- +goose Up
+-- Deleting a chirp detaches its replies rather than deleting them: they stay
+-- up as top-level chirps.
+ALTER TABLE chirps
+ADD COLUMN in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_in_reply_to_created_at_id_idx ON chirps (in_reply_to, created_at, id);
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN in_reply_to;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- A rechirp reposts ref_chirp_id as is and has an empty body; a quote adds
+-- its own body. Rechirps are deleted along with the chirp they repost (see
+-- DeleteChirpById), while quotes stay up with ref_chirp_id set to null.
+ALTER TABLE chirps
+ADD COLUMN kind TEXT NOT NULL DEFAULT 'chirp' CHECK (kind IN ('chirp', 'rechirp', 'quote')),
+ADD COLUMN ref_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX chirps_user_id_rechirp_idx ON chirps (user_id, ref_chirp_id) WHERE kind = 'rechirp';
+CREATE INDEX chirps_ref_chirp_id_idx ON chirps (ref_chirp_id);
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN ref_chirp_id;
+ALTER TABLE chirps DROP COLUMN kind;
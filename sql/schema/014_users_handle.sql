@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN handle TEXT;
+
+-- Existing users get a placeholder handle made from their id.
+UPDATE users
+SET handle = 'user_' || substr(replace(id::text, '-', ''), 1, 10);
+
+ALTER TABLE users
+ALTER COLUMN handle SET NOT NULL,
+ADD CONSTRAINT users_handle_key UNIQUE (handle);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN handle;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE flagged_chirps (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    words TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX flagged_chirps_created_at_idx ON flagged_chirps (created_at DESC, chirp_id DESC);
+
+-- +goose Down
+DROP TABLE flagged_chirps;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Refresh tokens are stored as the hex SHA-256 of the token handed to the
+-- client. Hashing the existing rows in place keeps current sessions working.
+UPDATE refresh_tokens
+SET token = encode(sha256(convert_to(token, 'UTF8')), 'hex'),
+    parent_token = encode(sha256(convert_to(parent_token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token TO token_hash;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN parent_token TO parent_token_hash;
+
+-- +goose Down
+-- The raw tokens cannot be recovered, so every session is signed out.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN parent_token_hash TO parent_token;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token_hash TO token;
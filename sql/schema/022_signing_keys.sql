@@ -0,0 +1,14 @@
+-- +goose Up
+-- Private keys for signing access tokens, named by the kid they put in the
+-- token header. A key signs until a newer one replaces it, and is still
+-- published and accepted until retires_at.
+CREATE TABLE signing_keys (
+    id TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL CHECK (algorithm IN ('EdDSA', 'RS256')),
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    retires_at TIMESTAMP
+    );
+
+-- +goose Down
+DROP TABLE signing_keys;
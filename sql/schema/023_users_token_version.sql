@@ -0,0 +1,9 @@
+-- +goose Up
+-- Access tokens carry the token_version they were issued under and are
+-- rejected once it has been bumped.
+ALTER TABLE users
+ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN token_version;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Long-lived keys users make for bots and scripts. Only a SHA-256 hash of
+-- each key is stored; prefix is the start of the key, kept so users can
+-- tell their keys apart.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scope TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP
+    );
+
+CREATE INDEX api_keys_user_id_created_at_idx ON api_keys (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE api_keys;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- A user's TOTP secret. It only protects their logins once confirmed_at is
+-- set, which happens when they prove their authenticator has it.
+-- last_used_step is the time step of the last code accepted, so that no
+-- code can be used twice.
+CREATE TABLE user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0
+    );
+
+-- One-time codes for logging in without the authenticator. Only their
+-- SHA-256 hashes are stored.
+CREATE TABLE recovery_codes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    PRIMARY KEY (user_id, code_hash)
+    );
+
+-- Logins that got the password right and are waiting for a second factor.
+-- Each is named by the hash of the mfa_token handed out for it.
+CREATE TABLE mfa_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0
+    );
+
+-- +goose Down
+DROP TABLE mfa_challenges;
+DROP TABLE recovery_codes;
+DROP TABLE user_totp;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Outstanding password reset tokens. Only a SHA-256 hash of each token is
+-- stored, and a token is deleted when it is used.
+CREATE TABLE password_resets (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX password_resets_user_id_idx ON password_resets (user_id);
+
+-- +goose Down
+DROP TABLE password_resets;
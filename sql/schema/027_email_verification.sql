@@ -0,0 +1,26 @@
+-- +goose Up
+-- verified_at is when the user proved they can read mail sent to email.
+-- pending_email is an address they have asked to change to, which only
+-- replaces email once it is verified.
+ALTER TABLE users
+ADD COLUMN verified_at TIMESTAMP,
+ADD COLUMN pending_email TEXT;
+
+-- Outstanding email verification tokens, each for the address it was sent
+-- to. Only a SHA-256 hash of each token is stored, and a token is deleted
+-- when it is used.
+CREATE TABLE email_verifications (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX email_verifications_user_id_idx ON email_verifications (user_id);
+
+-- +goose Down
+DROP TABLE email_verifications;
+ALTER TABLE users
+DROP COLUMN pending_email,
+DROP COLUMN verified_at;
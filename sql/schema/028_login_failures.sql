@@ -0,0 +1,11 @@
+-- +goose Up
+-- Failed logins counted per account or client IP, when LOGIN_LOCKOUT_STORE
+-- is db. key is "account:<email>" or "ip:<address>".
+CREATE TABLE login_failures (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failed_at TIMESTAMP NOT NULL
+    );
+
+-- +goose Down
+DROP TABLE login_failures;
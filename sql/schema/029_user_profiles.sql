@@ -0,0 +1,10 @@
+-- +goose Up
+-- Optional profile fields users set for themselves. NULL means unset.
+ALTER TABLE users
+ADD COLUMN display_name TEXT,
+ADD COLUMN bio TEXT;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN bio,
+DROP COLUMN display_name;